@@ -0,0 +1,227 @@
+package business
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Aging buckets, by days past the due date
+const (
+	BucketCurrent = iota // not yet due
+	Bucket0To30
+	Bucket31To60
+	Bucket61To90
+	BucketOver90
+	NumBuckets
+)
+
+// BucketLabels are the labels of the aging buckets
+var BucketLabels = [NumBuckets]string{"Current", "0-30", "31-60", "61-90", "90+"}
+
+// AgingItem type: the amount outstanding of a lot (an invoice with its
+// payments, or a payment not yet applied) or of a split without lot
+type AgingItem struct {
+	Owner   *Owner   // nil if unknown
+	Invoice *Invoice // nil if the lot is not of an invoice
+	Account *model.Account
+	Lot     *model.Lot // nil for a split without lot
+	Date    time.Time  // date posted of the invoice, or of the first split
+	Due     time.Time
+	Days    int // days past the due date, negative if not yet due
+	Bucket  int
+	Amount  numeric.Numeric
+}
+
+// AgingRow type: the amounts outstanding of an owner in a commodity
+// by bucket
+type AgingRow struct {
+	Owner     *Owner // nil for the amounts without owner
+	Commodity string // of the accounts
+	Buckets   [NumBuckets]numeric.Numeric
+	Total     numeric.Numeric
+	Items     []*AgingItem // sorted by due date
+}
+
+// AgingReport type: the receivable or payable amounts outstanding at a
+// date. The amounts have the natural sign of the accounts: positive for
+// the amounts due by the customers (RECEIVABLE) or to the vendors and
+// employees (PAYABLE).
+type AgingReport struct {
+	AccountType string // RECEIVABLE or PAYABLE
+	Date        time.Time
+	Accounts    []*model.Account
+	Rows        []*AgingRow                // sorted by owner name, without owner last, and commodity
+	Totals      []*AgingRow                // without owner, one for each commodity, sorted by commodity
+	Balances    map[string]numeric.Numeric // of the accounts at Date, by commodity
+	Warnings    []string                   // the accounts whose amounts don't match the balance
+}
+
+// bucket returns the bucket of the days past the due date
+func bucket(days int) int {
+	switch {
+	case days < 0:
+		return BucketCurrent
+	case days <= 30:
+		return Bucket0To30
+	case days <= 60:
+		return Bucket31To60
+	case days <= 90:
+		return Bucket61To90
+	}
+	return BucketOver90
+}
+
+// daysBetween returns the number of calendar days from the day of t1
+// to the day of t2
+func daysBetween(t1, t2 time.Time) int {
+	d1 := time.Date(t1.Year(), t1.Month(), t1.Day(), 12, 0, 0, 0, time.UTC)
+	d2 := time.Date(t2.Year(), t2.Month(), t2.Day(), 12, 0, 0, 0, time.UTC)
+	return int(d2.Sub(d1).Hours() / 24)
+}
+
+// Aging returns the aging report of the accounts of the type (RECEIVABLE
+// or PAYABLE) at the end of the day at. The splits of each lot are paired
+// and the lot balance is aged by the due date of its invoice, or by the
+// date of its first split if the lot has no invoice. The splits without
+// lot are aged by their date. The amounts of each account are checked
+// against its balance at the date, adding a warning if they differ.
+func (b *Business) Aging(accountType string, at time.Time) *AgingReport {
+	r := &AgingReport{AccountType: accountType, Date: at, Balances: map[string]numeric.Numeric{}}
+	end := endOfDay(at)
+	type rowKey struct {
+		owner     *Owner
+		commodity string
+	}
+	rows := map[rowKey]*AgingRow{}
+	totals := map[string]*AgingRow{}
+
+	add := func(item *AgingItem) {
+		if item.Amount.Sign() == 0 {
+			return
+		}
+		item.Days = daysBetween(item.Due, at)
+		item.Bucket = bucket(item.Days)
+		key := rowKey{item.Owner, item.Account.Currency}
+		row := rows[key]
+		if row == nil {
+			row = &AgingRow{Owner: item.Owner, Commodity: key.commodity}
+			rows[key] = row
+			r.Rows = append(r.Rows, row)
+		}
+		for _, row := range []*AgingRow{row, totals[key.commodity]} {
+			row.Buckets[item.Bucket].AddEqual(&item.Amount)
+			row.Total.AddEqual(&item.Amount)
+			row.Items = append(row.Items, item)
+		}
+	}
+
+	b.Book.Accounts.Walk(func(a *model.Account, level int) {
+		if a.Type.Code() != accountType {
+			return
+		}
+		r.Accounts = append(r.Accounts, a)
+		if totals[a.Currency] == nil {
+			totals[a.Currency] = &AgingRow{Commodity: a.Currency}
+			r.Totals = append(r.Totals, totals[a.Currency])
+		}
+		invert := a.Type.InvertValues()
+
+		var balance, total numeric.Numeric
+		lots := map[*model.Lot]*AgingItem{}
+		var list []*AgingItem // lots in order of first split
+		for _, act := range a.AccountTransactionList {
+			if act.Transaction.DatePosted.After(end) {
+				break
+			}
+			balance = act.Balance
+			v := act.Split.Quantity
+			if invert {
+				v.NegEqual()
+			}
+
+			lot := act.Split.Lot
+			if lot == nil {
+				list = append(list, &AgingItem{
+					Account: a,
+					Date:    act.Transaction.DatePosted,
+					Due:     act.Transaction.DatePosted,
+					Amount:  v,
+				})
+				continue
+			}
+			item := lots[lot]
+			if item == nil {
+				item = &AgingItem{
+					Account: a,
+					Lot:     lot,
+					Date:    act.Transaction.DatePosted,
+					Due:     act.Transaction.DatePosted,
+					Owner:   b.owners[lot.Slots.Value("gncOwner/owner-guid")],
+				}
+				if inv := b.lots[lot]; inv != nil && !inv.Posted.After(end) {
+					item.Invoice, item.Owner = inv, inv.Owner
+					item.Date, item.Due = inv.Posted, inv.DueDate()
+				}
+				lots[lot] = item
+				list = append(list, item)
+			}
+			item.Amount.AddEqual(&v)
+		}
+		for _, item := range list {
+			total.AddEqual(&item.Amount)
+			add(item)
+		}
+
+		if invert {
+			balance.NegEqual()
+		}
+		if numeric.Cmp(&total, &balance) != 0 {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s: amounts outstanding %s differ from the balance %s",
+				a.FullName(), total.DecimalString(2), balance.DecimalString(2)))
+		}
+		v := r.Balances[a.Currency]
+		v.AddEqual(&balance)
+		r.Balances[a.Currency] = v
+	})
+
+	sort.Sort(byAgingOwner(r.Rows))
+	for _, row := range r.Rows {
+		row.sortItems()
+	}
+	sort.Sort(byAgingOwner(r.Totals))
+	for _, row := range r.Totals {
+		row.sortItems()
+	}
+	return r
+}
+
+// sortItems sorts the items of the row by due date
+func (row *AgingRow) sortItems() {
+	sort.SliceStable(row.Items, func(i, j int) bool { return row.Items[i].Due.Before(row.Items[j].Due) })
+}
+
+// used to sort AgingRows
+type byAgingOwner []*AgingRow
+
+func (a byAgingOwner) Len() int      { return len(a) }
+func (a byAgingOwner) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byAgingOwner) Less(i, j int) bool {
+	oi, oj := a[i].Owner, a[j].Owner
+	if oi != oj {
+		if oi == nil || oj == nil {
+			return oj == nil
+		}
+		if c := strings.Compare(strings.ToLower(oi.Name), strings.ToLower(oj.Name)); c != 0 {
+			return c < 0
+		}
+		if oi.GUID != oj.GUID {
+			return oi.GUID < oj.GUID
+		}
+	}
+	return a[i].Commodity < a[j].Commodity
+}
@@ -0,0 +1,443 @@
+// Package business reads the business data of a GnuCash book (customers,
+// vendors, employees, jobs, invoices and bills with their entries, tax
+// tables and billing terms) and links it to the accounts, transactions
+// and lots of the model.
+package business
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// Owner types
+const (
+	OwnerCustomer = "gncCustomer"
+	OwnerVendor   = "gncVendor"
+	OwnerEmployee = "gncEmployee"
+	OwnerJob      = "gncJob"
+)
+
+// Bill term types
+const (
+	TermDays    = "days"
+	TermProximo = "proximo"
+)
+
+// Business type
+type Business struct {
+	Book      *model.Book
+	Customers []*Owner // sorted by name
+	Vendors   []*Owner // sorted by name
+	Employees []*Owner // sorted by name
+	Jobs      []*Job
+	Invoices  []*Invoice // sorted by date opened
+	TaxTables []*TaxTable
+	BillTerms []*BillTerm
+	owners    map[string]*Owner       // by GUID
+	lots      map[*model.Lot]*Invoice // by posted lot
+}
+
+// Address type
+type Address struct {
+	Name  string
+	Addr1 string
+	Addr2 string
+	Addr3 string
+	Addr4 string
+	Phone string
+	Fax   string
+	Email string
+}
+
+// Owner type: a customer, vendor or employee
+type Owner struct {
+	GUID     string
+	Type     string // OwnerCustomer, OwnerVendor or OwnerEmployee
+	ID       string
+	Name     string
+	Address  Address
+	Notes    string
+	Active   bool
+	Currency string
+	Terms    *BillTerm // nil if none
+	TaxTable *TaxTable // nil if none
+	Slots    model.Slots
+	Jobs     []*Job
+	Invoices []*Invoice // sorted by date opened
+}
+
+// Job type
+type Job struct {
+	GUID      string
+	ID        string
+	Name      string
+	Reference string
+	Owner     *Owner
+	Active    bool
+}
+
+// TaxTable type
+type TaxTable struct {
+	GUID    string
+	Name    string
+	Entries []*TaxTableEntry
+}
+
+// TaxTableEntry type: Amount is a percentage if Type is PERCENT,
+// a value if Type is VALUE
+type TaxTableEntry struct {
+	Account *model.Account
+	Amount  numeric.Numeric
+	Type    string
+}
+
+// BillTerm type: the payment terms of an invoice.
+// With TermDays the invoice is due DueDays after the date posted; with
+// TermProximo it is due on the DueDay of the next month, or of the month
+// after if posted after the CutoffDay.
+type BillTerm struct {
+	GUID         string
+	Name         string
+	Description  string
+	Type         string // TermDays or TermProximo
+	DueDays      int
+	DiscountDays int
+	Discount     numeric.Numeric
+	DueDay       int
+	DiscountDay  int
+	CutoffDay    int
+}
+
+func loadError(object, field, id string, err error) error {
+	return &model.LoadError{Object: object, ID: id, Field: field, Err: err}
+}
+
+func parseBool(value string) bool {
+	return value == "1"
+}
+
+// parseTime parses a GnuCash time stamp; the empty string is the zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02 15:04:05 -0700", value)
+}
+
+// parseNumeric parses a GnuCash numeric; the empty string is zero
+func parseNumeric(value string) (numeric.Numeric, error) {
+	if value == "" {
+		return numeric.Numeric{}, nil
+	}
+	return numeric.FromString(value)
+}
+
+// parseInt parses an integer; the empty string is zero
+func parseInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// New returns the business data of the book, read from the XML book the
+// model book was loaded from. The first invalid record is an error.
+func New(xmlBook *gncxml.Book, book *model.Book) (*Business, error) {
+	return Load(xmlBook, book, model.NewErrorCollector(false))
+}
+
+// Load returns the business data of the book like New, handling the
+// errors of the records with errs. In lenient mode the invalid bill
+// terms, tax tables, jobs, invoices and entries are skipped, while the
+// owners with a missing bill term or tax table are kept without it.
+func Load(xmlBook *gncxml.Book, book *model.Book, errs *model.ErrorCollector) (*Business, error) {
+	b := &Business{Book: book, owners: map[string]*Owner{}, lots: map[*model.Lot]*Invoice{}}
+
+	terms := map[string]*BillTerm{}
+	for j := range xmlBook.BillTermList {
+		t, err := newBillTermFromXML(&xmlBook.BillTermList[j])
+		if err != nil {
+			if err = errs.Add(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		terms[t.GUID] = t
+		b.BillTerms = append(b.BillTerms, t)
+	}
+
+	taxTables := map[string]*TaxTable{}
+	for j := range xmlBook.TaxTableList {
+		t, err := newTaxTableFromXML(&xmlBook.TaxTableList[j], book.Accounts)
+		if err != nil {
+			if err = errs.Add(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		taxTables[t.GUID] = t
+		b.TaxTables = append(b.TaxTables, t)
+	}
+
+	// owners
+	for j := range xmlBook.CustomerList {
+		x := &xmlBook.CustomerList[j]
+		o := &Owner{
+			GUID:     x.GUID,
+			Type:     OwnerCustomer,
+			ID:       x.ID,
+			Name:     x.Name,
+			Address:  Address(x.Address),
+			Notes:    x.Notes,
+			Active:   parseBool(x.Active),
+			Currency: x.Currency,
+			Slots:    model.NewSlotsFromXML(x.Slots),
+		}
+		if err := o.setTerms(x.TermsID, x.TaxTableID, terms, taxTables, errs); err != nil {
+			return nil, err
+		}
+		b.Customers = append(b.Customers, o)
+		b.owners[o.GUID] = o
+	}
+	for j := range xmlBook.VendorList {
+		x := &xmlBook.VendorList[j]
+		o := &Owner{
+			GUID:     x.GUID,
+			Type:     OwnerVendor,
+			ID:       x.ID,
+			Name:     x.Name,
+			Address:  Address(x.Address),
+			Notes:    x.Notes,
+			Active:   parseBool(x.Active),
+			Currency: x.Currency,
+			Slots:    model.NewSlotsFromXML(x.Slots),
+		}
+		if err := o.setTerms(x.TermsID, x.TaxTableID, terms, taxTables, errs); err != nil {
+			return nil, err
+		}
+		b.Vendors = append(b.Vendors, o)
+		b.owners[o.GUID] = o
+	}
+	for j := range xmlBook.EmployeeList {
+		x := &xmlBook.EmployeeList[j]
+		o := &Owner{
+			GUID:     x.GUID,
+			Type:     OwnerEmployee,
+			ID:       x.ID,
+			Name:     x.Address.Name,
+			Address:  Address(x.Address),
+			Active:   parseBool(x.Active),
+			Currency: x.Currency,
+			Slots:    model.NewSlotsFromXML(x.Slots),
+		}
+		if o.Name == "" {
+			o.Name = x.Username
+		}
+		b.Employees = append(b.Employees, o)
+		b.owners[o.GUID] = o
+	}
+	sort.Sort(byOwnerName(b.Customers))
+	sort.Sort(byOwnerName(b.Vendors))
+	sort.Sort(byOwnerName(b.Employees))
+
+	// jobs
+	jobs := map[string]*Job{}
+	for j := range xmlBook.JobList {
+		x := &xmlBook.JobList[j]
+		owner := b.owners[x.Owner.ID]
+		if owner == nil {
+			if err := errs.Add(loadError("Job", "Owner", x.GUID, fmt.Errorf("Owner not found: %s", x.Owner.ID))); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		job := &Job{
+			GUID:      x.GUID,
+			ID:        x.ID,
+			Name:      x.Name,
+			Reference: x.Reference,
+			Owner:     owner,
+			Active:    parseBool(x.Active),
+		}
+		owner.Jobs = append(owner.Jobs, job)
+		jobs[job.GUID] = job
+		b.Jobs = append(b.Jobs, job)
+	}
+
+	// invoices
+	transactions := map[string]*model.Transaction{}
+	for _, t := range book.Transactions {
+		transactions[t.ID] = t
+	}
+	invoices := map[string]*Invoice{}
+	for j := range xmlBook.InvoiceList {
+		inv, err := b.newInvoiceFromXML(&xmlBook.InvoiceList[j], jobs, terms, transactions)
+		if err != nil {
+			// e.g. the posted transaction has been skipped
+			if err = errs.Add(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		inv.Owner.Invoices = append(inv.Owner.Invoices, inv)
+		if inv.Lot != nil {
+			b.lots[inv.Lot] = inv
+		}
+		invoices[inv.GUID] = inv
+		b.Invoices = append(b.Invoices, inv)
+	}
+	sort.Sort(byInvoiceOpened(b.Invoices))
+	for _, o := range b.owners {
+		sort.Sort(byInvoiceOpened(o.Invoices))
+	}
+
+	// entries
+	for j := range xmlBook.EntryList {
+		if err := newEntryFromXML(&xmlBook.EntryList[j], book.Accounts, invoices, taxTables); err != nil {
+			if err = errs.Add(err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, inv := range b.Invoices {
+		sort.Sort(byEntryDate(inv.Entries))
+	}
+
+	return b, nil
+}
+
+// setTerms sets the bill term and the tax table of the owner. Each one
+// not found is added to errs: in lenient mode the other is set all the same.
+func (o *Owner) setTerms(termsID, taxTableID string, terms map[string]*BillTerm, taxTables map[string]*TaxTable, errs *model.ErrorCollector) error {
+	object := "Customer"
+	if o.Type == OwnerVendor {
+		object = "Vendor"
+	}
+	if taxTableID != "" {
+		if o.TaxTable = taxTables[taxTableID]; o.TaxTable == nil {
+			if err := errs.Add(loadError(object, "TaxTable", o.GUID, fmt.Errorf("Tax table not found: %s", taxTableID))); err != nil {
+				return err
+			}
+		}
+	}
+	if termsID != "" {
+		if o.Terms = terms[termsID]; o.Terms == nil {
+			if err := errs.Add(loadError(object, "Terms", o.GUID, fmt.Errorf("Bill term not found: %s", termsID))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newBillTermFromXML(x *gncxml.BillTerm) (*BillTerm, error) {
+	t := &BillTerm{GUID: x.GUID, Name: x.Name, Description: x.Description}
+	d := x.Days
+	t.Type = TermDays
+	if d == nil {
+		d, t.Type = x.Proximo, TermProximo
+	}
+	if d == nil {
+		return nil, loadError("BillTerm", "Type", x.GUID, fmt.Errorf("Days or proximo not found"))
+	}
+	var err error
+	for _, f := range []struct {
+		name  string
+		value string
+		dest  *int
+	}{
+		{"DueDays", d.DueDays, &t.DueDays},
+		{"DiscountDays", d.DiscountDays, &t.DiscountDays},
+		{"DueDay", d.DueDay, &t.DueDay},
+		{"DiscountDay", d.DiscountDay, &t.DiscountDay},
+		{"CutoffDay", d.CutoffDay, &t.CutoffDay},
+	} {
+		if *f.dest, err = parseInt(f.value); err != nil {
+			return nil, loadError("BillTerm", f.name, x.GUID, err)
+		}
+	}
+	if t.Discount, err = parseNumeric(d.Discount); err != nil {
+		return nil, loadError("BillTerm", "Discount", x.GUID, err)
+	}
+	return t, nil
+}
+
+func newTaxTableFromXML(x *gncxml.TaxTable, accounts *model.Accounts) (*TaxTable, error) {
+	t := &TaxTable{GUID: x.GUID, Name: x.Name}
+	for _, xe := range x.Entries {
+		account := accounts.Map[xe.AccountID]
+		if account == nil {
+			return nil, loadError("TaxTable", "Account", x.GUID, fmt.Errorf("Account not found: %s", xe.AccountID))
+		}
+		amount, err := parseNumeric(xe.Amount)
+		if err != nil {
+			return nil, loadError("TaxTable", "Amount", x.GUID, err)
+		}
+		t.Entries = append(t.Entries, &TaxTableEntry{Account: account, Amount: amount, Type: xe.Type})
+	}
+	return t, nil
+}
+
+// DueDate returns the due date of an invoice posted at the date.
+func (t *BillTerm) DueDate(posted time.Time) time.Time {
+	if t.Type == TermDays {
+		return posted.AddDate(0, 0, t.DueDays)
+	}
+	months := 1
+	if t.CutoffDay > 0 && posted.Day() > t.CutoffDay {
+		months = 2
+	}
+	first := time.Date(posted.Year(), posted.Month(), 1, 0, 0, 0, 0, posted.Location()).AddDate(0, months, 0)
+	d := t.DueDay
+	if last := first.AddDate(0, 1, -1).Day(); d > last || d <= 0 {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// Owners returns the customers, vendors and employees.
+func (b *Business) Owners() []*Owner {
+	list := make([]*Owner, 0, len(b.owners))
+	list = append(list, b.Customers...)
+	list = append(list, b.Vendors...)
+	return append(list, b.Employees...)
+}
+
+// Owner returns the owner with the given name or ID, or nil if not found.
+func (b *Business) Owner(name string) *Owner {
+	for _, o := range b.Owners() {
+		if o.Name == name || o.ID == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// TypeLabel returns the label of the owner type
+func (o *Owner) TypeLabel() string {
+	switch o.Type {
+	case OwnerCustomer:
+		return "Customer"
+	case OwnerVendor:
+		return "Vendor"
+	case OwnerEmployee:
+		return "Employee"
+	}
+	return o.Type
+}
+
+// used to sort Owners
+type byOwnerName []*Owner
+
+func (a byOwnerName) Len() int      { return len(a) }
+func (a byOwnerName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byOwnerName) Less(i, j int) bool {
+	return strings.Compare(strings.ToLower(a[i].Name), strings.ToLower(a[j].Name)) < 0
+}
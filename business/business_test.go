@@ -0,0 +1,107 @@
+package business
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+func TestBillTermDueDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		term   BillTerm
+		posted string
+		want   string
+	}{
+		{"days", BillTerm{Type: TermDays, DueDays: 30}, "2015-01-15", "2015-02-14"},
+		{"days across the year", BillTerm{Type: TermDays, DueDays: 60}, "2015-12-15", "2016-02-13"},
+		{"no days", BillTerm{Type: TermDays}, "2015-01-15", "2015-01-15"},
+		{"proximo", BillTerm{Type: TermProximo, DueDay: 10}, "2015-01-20", "2015-02-10"},
+		{"proximo before the cutoff", BillTerm{Type: TermProximo, DueDay: 10, CutoffDay: 25}, "2015-01-25", "2015-02-10"},
+		{"proximo after the cutoff", BillTerm{Type: TermProximo, DueDay: 10, CutoffDay: 25}, "2015-01-26", "2015-03-10"},
+		{"proximo across the year", BillTerm{Type: TermProximo, DueDay: 10}, "2015-12-20", "2016-01-10"},
+		{"proximo day beyond the month", BillTerm{Type: TermProximo, DueDay: 31}, "2015-01-10", "2015-02-28"},
+		{"proximo day in a leap year", BillTerm{Type: TermProximo, DueDay: 30}, "2016-01-10", "2016-02-29"},
+		{"proximo without a day", BillTerm{Type: TermProximo}, "2015-03-10", "2015-04-30"},
+	}
+	for _, tt := range tests {
+		posted, err := time.Parse("2006-01-02", tt.posted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tt.term.DueDate(posted).Format("2006-01-02"); got != tt.want {
+			t.Errorf("%s: DueDate(%s) = %s, want %s", tt.name, tt.posted, got, tt.want)
+		}
+	}
+}
+
+// testReceivable returns a RECEIVABLE account in the currency with a
+// split without lot for each date and amount
+func testReceivable(name, currency string, splits ...string) *model.Account {
+	typ := model.AccountTypes["RECEIVABLE"]
+	a := &model.Account{ID: name, Name: name, Type: &typ, Currency: currency}
+	var balance numeric.Numeric
+	for j := 0; j < len(splits); j += 2 {
+		date, err := time.Parse("2006-01-02", splits[j])
+		if err != nil {
+			panic(err)
+		}
+		v, err := numeric.FromDecimal(splits[j+1])
+		if err != nil {
+			panic(err)
+		}
+		balance.AddEqual(&v)
+		a.AccountTransactionList = append(a.AccountTransactionList, &model.AccountTransaction{
+			Transaction: &model.Transaction{ID: name + splits[j], Currency: currency, DatePosted: date},
+			Split:       &model.Split{Account: a, Value: v, Quantity: v},
+			Balance:     balance,
+		})
+	}
+	return a
+}
+
+func TestAging(t *testing.T) {
+	rootType := model.AccountTypes["ROOT"]
+	eur := testReceivable("Crediti EUR", "EUR", "2015-01-10", "100", "2015-02-10", "-30", "2015-04-10", "10")
+	usd := testReceivable("Crediti USD", "USD", "2015-03-01", "50")
+	root := &model.Account{Name: "Root", Type: &rootType, Children: []*model.Account{eur, usd}}
+	b := &Business{Book: &model.Book{Accounts: &model.Accounts{Root: root}}}
+
+	at, _ := time.Parse("2006-01-02", "2015-03-31")
+	rows := func(list []*AgingRow) string {
+		var s []string
+		for _, row := range list {
+			buckets := make([]string, len(row.Buckets))
+			for j := range row.Buckets {
+				buckets[j] = row.Buckets[j].DecimalString(2)
+			}
+			s = append(s, row.Commodity+" "+strings.Join(buckets, " ")+" "+row.Total.DecimalString(2))
+		}
+		return strings.Join(s, "; ")
+	}
+
+	r := b.Aging("RECEIVABLE", at)
+	if got, want := rows(r.Rows), "EUR 0.00 0.00 -30.00 100.00 0.00 70.00; USD 0.00 50.00 0.00 0.00 0.00 50.00"; got != want {
+		t.Errorf("rows %q, want %q", got, want)
+	}
+	if got, want := rows(r.Totals), "EUR 0.00 0.00 -30.00 100.00 0.00 70.00; USD 0.00 50.00 0.00 0.00 0.00 50.00"; got != want {
+		t.Errorf("totals %q, want %q", got, want)
+	}
+	for c, want := range map[string]string{"EUR": "70.00", "USD": "50.00"} {
+		if v := r.Balances[c]; v.DecimalString(2) != want {
+			t.Errorf("balance in %s %s, want %s", c, v.DecimalString(2), want)
+		}
+	}
+	if len(r.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %q", r.Warnings)
+	}
+
+	// a balance not matching the amounts
+	eur.AccountTransactionList[1].Balance, _ = numeric.FromDecimal("80")
+	if r = b.Aging("RECEIVABLE", at); len(r.Warnings) != 1 {
+		t.Errorf("warnings %q, want one for %s", r.Warnings, eur.Name)
+	}
+}
@@ -0,0 +1,354 @@
+package business
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// Invoice types, by owner type
+const (
+	TypeInvoice = "invoice" // customer invoice
+	TypeBill    = "bill"    // vendor bill
+	TypeVoucher = "voucher" // employee expense voucher
+)
+
+// Invoice type: a customer invoice, a vendor bill or an employee voucher.
+// Once posted, the invoice has a transaction in a RECEIVABLE or PAYABLE
+// account, whose split opens a lot collecting the payments.
+type Invoice struct {
+	GUID        string
+	ID          string
+	Owner       *Owner // for the invoices of a job, the owner of the job
+	Job         *Job   // nil if the invoice is not for a job
+	BillingID   string
+	Notes       string
+	Active      bool
+	Opened      time.Time
+	Posted      time.Time // zero if not posted
+	Terms       *BillTerm // nil if none
+	Currency    string
+	Account     *model.Account     // nil if not posted
+	Transaction *model.Transaction // nil if not posted
+	Lot         *model.Lot         // nil if not posted
+	Entries     []*Entry           // sorted by date
+	Slots       model.Slots
+}
+
+// Entry type: a line of an invoice
+type Entry struct {
+	GUID         string
+	Invoice      *Invoice
+	Date         time.Time
+	Description  string
+	Action       string
+	Notes        string
+	Quantity     numeric.Numeric
+	Price        numeric.Numeric
+	Discount     numeric.Numeric // customer invoices only
+	DiscountType string          // PERCENT or VALUE
+	Account      *model.Account
+	Taxable      bool
+	TaxIncluded  bool
+	TaxTable     *TaxTable // nil if none
+}
+
+func (b *Business) newInvoiceFromXML(x *gncxml.Invoice, jobs map[string]*Job, terms map[string]*BillTerm, transactions map[string]*model.Transaction) (*Invoice, error) {
+	inv := &Invoice{
+		GUID:      x.GUID,
+		ID:        x.ID,
+		BillingID: x.BillingID,
+		Notes:     x.Notes,
+		Active:    parseBool(x.Active),
+		Currency:  x.Currency,
+		Slots:     model.NewSlotsFromXML(x.Slots),
+	}
+
+	// owner
+	if x.Owner.Type == OwnerJob {
+		if inv.Job = jobs[x.Owner.ID]; inv.Job == nil {
+			return nil, loadError("Invoice", "Owner", x.GUID, fmt.Errorf("Job not found: %s", x.Owner.ID))
+		}
+		inv.Owner = inv.Job.Owner
+	} else if inv.Owner = b.owners[x.Owner.ID]; inv.Owner == nil {
+		return nil, loadError("Invoice", "Owner", x.GUID, fmt.Errorf("Owner not found: %s", x.Owner.ID))
+	}
+
+	var err error
+	if inv.Opened, err = parseTime(x.Opened); err != nil {
+		return nil, loadError("Invoice", "Opened", x.GUID, err)
+	}
+	if inv.Posted, err = parseTime(x.Posted); err != nil {
+		return nil, loadError("Invoice", "Posted", x.GUID, err)
+	}
+	if x.TermsID != "" {
+		if inv.Terms = terms[x.TermsID]; inv.Terms == nil {
+			return nil, loadError("Invoice", "Terms", x.GUID, fmt.Errorf("Bill term not found: %s", x.TermsID))
+		}
+	}
+
+	// posting
+	if x.PostAccountID != "" {
+		if inv.Account = b.Book.Accounts.Map[x.PostAccountID]; inv.Account == nil {
+			return nil, loadError("Invoice", "PostAccount", x.GUID, fmt.Errorf("Account not found: %s", x.PostAccountID))
+		}
+	}
+	if x.PostTxnID != "" {
+		if inv.Transaction = transactions[x.PostTxnID]; inv.Transaction == nil {
+			return nil, loadError("Invoice", "PostTransaction", x.GUID, fmt.Errorf("Transaction not found: %s", x.PostTxnID))
+		}
+	}
+	if x.PostLotID != "" {
+		if inv.Account != nil {
+			inv.Lot = inv.Account.Lot(x.PostLotID)
+		}
+		if inv.Lot == nil {
+			return nil, loadError("Invoice", "PostLot", x.GUID, fmt.Errorf("Lot not found: %s", x.PostLotID))
+		}
+	}
+	return inv, nil
+}
+
+func newEntryFromXML(x *gncxml.Entry, accounts *model.Accounts, invoices map[string]*Invoice, taxTables map[string]*TaxTable) error {
+	e := &Entry{
+		GUID:        x.GUID,
+		Description: x.Description,
+		Action:      x.Action,
+		Notes:       x.Notes,
+	}
+	var err error
+	if e.Date, err = parseTime(x.Date); err != nil {
+		return loadError("Entry", "Date", x.GUID, err)
+	}
+	if e.Quantity, err = parseNumeric(x.Quantity); err != nil {
+		return loadError("Entry", "Quantity", x.GUID, err)
+	}
+
+	// the entry belongs to an invoice (i- fields) or to a bill (b- fields)
+	invoiceID, accountID, price, taxTableID := x.InvoiceID, x.InvoiceAccountID, x.InvoicePrice, x.InvoiceTaxTableID
+	e.Taxable, e.TaxIncluded = parseBool(x.InvoiceTaxable), parseBool(x.InvoiceTaxIncluded)
+	if invoiceID == "" {
+		invoiceID, accountID, price, taxTableID = x.BillID, x.BillAccountID, x.BillPrice, x.BillTaxTableID
+		e.Taxable, e.TaxIncluded = parseBool(x.BillTaxable), parseBool(x.BillTaxIncluded)
+	} else {
+		if e.Discount, err = parseNumeric(x.InvoiceDiscount); err != nil {
+			return loadError("Entry", "Discount", x.GUID, err)
+		}
+		e.DiscountType = x.InvoiceDiscType
+	}
+	if invoiceID == "" {
+		// an entry of an order or not yet in an invoice
+		return nil
+	}
+	if e.Invoice = invoices[invoiceID]; e.Invoice == nil {
+		return loadError("Entry", "Invoice", x.GUID, fmt.Errorf("Invoice not found: %s", invoiceID))
+	}
+	if e.Price, err = parseNumeric(price); err != nil {
+		return loadError("Entry", "Price", x.GUID, err)
+	}
+	if accountID != "" {
+		if e.Account = accounts.Map[accountID]; e.Account == nil {
+			return loadError("Entry", "Account", x.GUID, fmt.Errorf("Account not found: %s", accountID))
+		}
+	}
+	if taxTableID != "" {
+		if e.TaxTable = taxTables[taxTableID]; e.TaxTable == nil {
+			return loadError("Entry", "TaxTable", x.GUID, fmt.Errorf("Tax table not found: %s", taxTableID))
+		}
+	}
+	e.Invoice.Entries = append(e.Invoice.Entries, e)
+	return nil
+}
+
+// Amount returns quantity times price less the discount, without taxes.
+func (e *Entry) Amount() (numeric.Numeric, error) {
+	amount, err := numeric.Mul(&e.Quantity, &e.Price)
+	if err != nil || e.Discount.Sign() == 0 {
+		return amount, err
+	}
+	discount := e.Discount
+	if e.DiscountType != "VALUE" {
+		x, err := numeric.Mul(&amount, &e.Discount)
+		if err != nil {
+			return amount, err
+		}
+		hundred := numeric.New(100, 1)
+		if discount, err = numeric.Quo(&x, &hundred); err != nil {
+			return amount, err
+		}
+	}
+	return numeric.Sub(&amount, &discount), nil
+}
+
+// Type returns the type of the invoice: TypeInvoice, TypeBill or TypeVoucher.
+func (inv *Invoice) Type() string {
+	switch inv.Owner.Type {
+	case OwnerVendor:
+		return TypeBill
+	case OwnerEmployee:
+		return TypeVoucher
+	}
+	return TypeInvoice
+}
+
+// IsPosted returns true if the invoice has been posted to an account.
+func (inv *Invoice) IsPosted() bool {
+	return inv.Transaction != nil
+}
+
+// CreditNote returns true if the invoice is a credit note.
+func (inv *Invoice) CreditNote() bool {
+	return inv.Slots.Value("credit-note") == "1"
+}
+
+// natural returns the value of a split of the posted account with the
+// sign of the owner: positive for an amount due by a customer, or due to
+// a vendor or employee
+func (inv *Invoice) natural(v numeric.Numeric) numeric.Numeric {
+	if inv.Owner.Type != OwnerCustomer {
+		v.NegEqual()
+	}
+	return v
+}
+
+// Total returns the amount of the posted invoice in the commodity of its
+// account, including taxes, or the sum of the entry amounts, without
+// taxes, if not posted.
+func (inv *Invoice) Total() numeric.Numeric {
+	var total numeric.Numeric
+	if !inv.IsPosted() {
+		for _, e := range inv.Entries {
+			if amount, err := e.Amount(); err == nil {
+				total.AddEqual(&amount)
+			}
+		}
+		return total
+	}
+	for _, s := range inv.Transaction.Splits {
+		if s.Account == inv.Account {
+			total.AddEqual(&s.Quantity)
+		}
+	}
+	return inv.natural(total)
+}
+
+// Balance returns the amount still due at the end of the day at (zero
+// means at any date): the total less the payments in the lot of the
+// invoice. It is zero if the invoice is not posted at that date.
+func (inv *Invoice) Balance(at time.Time) numeric.Numeric {
+	var balance numeric.Numeric
+	if !inv.IsPosted() || (!at.IsZero() && inv.Posted.After(endOfDay(at))) {
+		return balance
+	}
+	if inv.Lot == nil {
+		return inv.Total()
+	}
+	for _, lat := range inv.Lot.AccountTransactionList {
+		if !at.IsZero() && lat.Transaction.DatePosted.After(endOfDay(at)) {
+			break
+		}
+		balance.AddEqual(&lat.Split.Quantity)
+	}
+	return inv.natural(balance)
+}
+
+// IsOpen returns true if the invoice is posted and not fully paid at the
+// end of the day at (zero means at any date).
+func (inv *Invoice) IsOpen(at time.Time) bool {
+	balance := inv.Balance(at)
+	return balance.Sign() != 0
+}
+
+// DueDate returns the due date of the posted invoice: the one of the
+// posted transaction, or computed with the bill term, or else the date
+// posted.
+func (inv *Invoice) DueDate() time.Time {
+	if inv.Transaction != nil {
+		if slot := inv.Transaction.Slots.Get("trans-date-due"); slot != nil {
+			if t, err := parseTime(slot.Value); err == nil && !t.IsZero() {
+				return t
+			}
+		}
+	}
+	if inv.Terms != nil && !inv.Posted.IsZero() {
+		return inv.Terms.DueDate(inv.Posted)
+	}
+	return inv.Posted
+}
+
+// endOfDay returns the last instant of the day of t
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// OpenInvoices returns the invoices not fully paid at the end of the day
+// at (zero means at any date) of the owners of the given type (all the
+// owners if empty), sorted by owner and due date.
+func (b *Business) OpenInvoices(ownerType string, at time.Time) []*Invoice {
+	var list []*Invoice
+	for _, inv := range b.Invoices {
+		if (ownerType == "" || inv.Owner.Type == ownerType) && inv.IsOpen(at) {
+			list = append(list, inv)
+		}
+	}
+	sort.Sort(byOwnerDue(list))
+	return list
+}
+
+// OpenInvoices returns the invoices of the owner not fully paid at the
+// end of the day at (zero means at any date), sorted by due date.
+func (o *Owner) OpenInvoices(at time.Time) []*Invoice {
+	var list []*Invoice
+	for _, inv := range o.Invoices {
+		if inv.IsOpen(at) {
+			list = append(list, inv)
+		}
+	}
+	sort.Sort(byOwnerDue(list))
+	return list
+}
+
+// Balance returns the amount due by (customers) or to (vendors and
+// employees) the owner at the end of the day at (zero means at any date).
+func (o *Owner) Balance(at time.Time) numeric.Numeric {
+	var balance numeric.Numeric
+	for _, inv := range o.Invoices {
+		b := inv.Balance(at)
+		balance.AddEqual(&b)
+	}
+	return balance
+}
+
+// used to sort Invoices
+type byInvoiceOpened []*Invoice
+
+func (a byInvoiceOpened) Len() int           { return len(a) }
+func (a byInvoiceOpened) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byInvoiceOpened) Less(i, j int) bool { return a[i].Opened.Before(a[j].Opened) }
+
+// used to sort Invoices
+type byOwnerDue []*Invoice
+
+func (a byOwnerDue) Len() int      { return len(a) }
+func (a byOwnerDue) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byOwnerDue) Less(i, j int) bool {
+	if a[i].Owner != a[j].Owner {
+		if c := strings.Compare(strings.ToLower(a[i].Owner.Name), strings.ToLower(a[j].Owner.Name)); c != 0 {
+			return c < 0
+		}
+		return a[i].Owner.GUID < a[j].Owner.GUID
+	}
+	return a[i].DueDate().Before(a[j].DueDate())
+}
+
+// used to sort Entries
+type byEntryDate []*Entry
+
+func (a byEntryDate) Len() int           { return len(a) }
+func (a byEntryDate) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byEntryDate) Less(i, j int) bool { return a[i].Date.Before(a[j].Date) }
@@ -0,0 +1,162 @@
+// Package classify suggests the account of a transaction from its
+// description, using the import-map-bayes data learned by GnuCash.
+//
+// GnuCash stores the map in the import-map-bayes frame slot of the account
+// a statement is imported into: for each token of the imported descriptions,
+// the accounts (by full name or, in newer versions, by ID) the token has been
+// assigned to, with the number of times.
+//
+//	<slot>
+//	  <slot:key>import-map-bayes</slot:key>
+//	  <slot:value type="frame">
+//	    <slot>
+//	      <slot:key>Arancio</slot:key>
+//	      <slot:value type="frame">
+//	        <slot>
+//	          <slot:key>Attività:Attività correnti:Conto corrente</slot:key>
+//	          <slot:value type="integer">1</slot:value>
+//	        </slot>
+//	      </slot:value>
+//	    </slot>
+//	  </slot:value>
+//	</slot>
+package classify
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+// SlotKey is the key of the account slot containing the bayes map
+const SlotKey = "import-map-bayes"
+
+// Classifier type
+type Classifier struct {
+	// token -> account -> count
+	tokens map[string]map[*model.Account]int
+}
+
+// Candidate type: an account with the probability to be the right one
+type Candidate struct {
+	Account     *model.Account
+	Probability float64
+}
+
+// New returns the classifier loaded from the bayes map of the account.
+// If account is nil, the maps of all the accounts of the book are merged.
+func New(book *model.Book, account *model.Account) *Classifier {
+	c := &Classifier{tokens: map[string]map[*model.Account]int{}}
+	if account != nil {
+		c.load(book, account)
+		return c
+	}
+	for _, a := range book.Accounts.Map {
+		c.load(book, a)
+	}
+	return c
+}
+
+func (c *Classifier) load(book *model.Book, account *model.Account) {
+	bayes := account.Slots.Get(SlotKey)
+	if bayes == nil {
+		return
+	}
+	for _, tokenSlot := range bayes.Slots {
+		token := normalize(tokenSlot.Key)
+		for _, accSlot := range tokenSlot.Slots {
+			acc := book.Accounts.Map[accSlot.Key]
+			if acc == nil {
+				acc = book.Accounts.ByFullName(accSlot.Key)
+			}
+			count, err := strconv.Atoi(accSlot.Value)
+			if acc == nil || err != nil || count <= 0 {
+				continue
+			}
+			m := c.tokens[token]
+			if m == nil {
+				m = map[*model.Account]int{}
+				c.tokens[token] = m
+			}
+			m[acc] += count
+		}
+	}
+}
+
+// Len returns the number of known tokens.
+func (c *Classifier) Len() int {
+	return len(c.tokens)
+}
+
+// normalize returns the token in the form used as map key
+func normalize(token string) string {
+	return strings.ToUpper(token)
+}
+
+// Tokens splits the text in tokens.
+func Tokens(text string) []string {
+	return strings.Fields(text)
+}
+
+// Classify returns the candidate accounts of the text, sorted by
+// decreasing probability.
+//
+// As in GnuCash, the probability of each account given a token is the
+// fraction of the token counts assigned to the account, and the
+// probabilities of the tokens are combined as
+//
+//	P = p1*p2*...*pn / (p1*p2*...*pn + (1-p1)*(1-p2)*...*(1-pn))
+func (c *Classifier) Classify(text string) []Candidate {
+	product := map[*model.Account]float64{}
+	productDiff := map[*model.Account]float64{}
+
+	seen := map[string]bool{}
+	for _, token := range Tokens(text) {
+		token = normalize(token)
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+
+		accounts := c.tokens[token]
+		total := 0
+		for _, count := range accounts {
+			total += count
+		}
+		for acc, count := range accounts {
+			p := float64(count) / float64(total)
+			if _, ok := product[acc]; !ok {
+				product[acc], productDiff[acc] = 1, 1
+			}
+			product[acc] *= p
+			productDiff[acc] *= 1 - p
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(product))
+	for acc, p := range product {
+		candidates = append(candidates, Candidate{
+			Account:     acc,
+			Probability: p / (p + productDiff[acc]),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Probability != candidates[j].Probability {
+			return candidates[i].Probability > candidates[j].Probability
+		}
+		return candidates[i].Account.FullName() < candidates[j].Account.FullName()
+	})
+	return candidates
+}
+
+// Best returns the most probable account of the text, or nil if none
+// has a probability of at least threshold.
+func (c *Classifier) Best(text string, threshold float64) *model.Account {
+	candidates := c.Classify(text)
+	if len(candidates) == 0 || candidates[0].Probability < threshold {
+		return nil
+	}
+	return candidates[0].Account
+}
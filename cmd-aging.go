@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/business"
+)
+
+var cmdAging = &command{
+	name:        "aging",
+	usage:       "aging [-type receivable|payable] [-date YYYY-MM-DD] [-detail] [-format table|json]  (amounts outstanding by days past due)",
+	runBusiness: runAging,
+}
+
+// agingItem type: the JSON form of a business.AgingItem
+type agingItem struct {
+	Invoice string `json:"invoice,omitempty"` // empty for payments and splits without invoice
+	Account string `json:"account"`
+	Date    string `json:"date"`
+	Due     string `json:"due"`
+	Days    int    `json:"days"`
+	Bucket  string `json:"bucket"`
+	Amount  string `json:"amount"`
+}
+
+// agingRow type: the JSON form of a business.AgingRow
+type agingRow struct {
+	Owner     string      `json:"owner,omitempty"` // empty for the totals and the amounts without owner
+	Commodity string      `json:"commodity"`
+	Buckets   []string    `json:"buckets"`
+	Total     string      `json:"total"`
+	Balance   string      `json:"balance,omitempty"` // of the accounts, only for the totals
+	Items     []agingItem `json:"items,omitempty"`
+}
+
+// agingResult type: the JSON form of a business.AgingReport
+type agingResult struct {
+	Type     string     `json:"type"`
+	Date     string     `json:"date"`
+	Labels   []string   `json:"labels"`
+	Rows     []agingRow `json:"rows"`
+	Totals   []agingRow `json:"totals"` // one for each commodity
+	Warnings []string   `json:"warnings,omitempty"`
+}
+
+func newAgingRow(row *business.AgingRow, detail bool) agingRow {
+	res := agingRow{Commodity: row.Commodity, Total: row.Total.DecimalString(2)}
+	if row.Owner != nil {
+		res.Owner = row.Owner.Name
+	}
+	for _, v := range row.Buckets {
+		res.Buckets = append(res.Buckets, v.DecimalString(2))
+	}
+	if !detail {
+		return res
+	}
+	for _, item := range row.Items {
+		ai := agingItem{
+			Account: item.Account.FullName(),
+			Date:    item.Date.Format("2006-01-02"),
+			Due:     item.Due.Format("2006-01-02"),
+			Days:    item.Days,
+			Bucket:  business.BucketLabels[item.Bucket],
+			Amount:  item.Amount.DecimalString(2),
+		}
+		if item.Invoice != nil {
+			ai.Invoice = item.Invoice.ID
+		}
+		res.Items = append(res.Items, ai)
+	}
+	return res
+}
+
+// aging returns the aging report of the RECEIVABLE (typ "receivable")
+// or PAYABLE (typ "payable") accounts at the date (empty for today)
+func aging(biz *business.Business, typ, date string, detail bool) (*agingResult, error) {
+	accountType := strings.ToUpper(typ)
+	if accountType != "RECEIVABLE" && accountType != "PAYABLE" {
+		return nil, fmt.Errorf("Invalid type: %s", typ)
+	}
+	at, err := parseDate(date, false)
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		at = today()
+	}
+
+	r := biz.Aging(accountType, at)
+	res := &agingResult{
+		Type:     typ,
+		Date:     at.Format("2006-01-02"),
+		Labels:   business.BucketLabels[:],
+		Rows:     []agingRow{},
+		Totals:   []agingRow{},
+		Warnings: r.Warnings,
+	}
+	for _, row := range r.Rows {
+		res.Rows = append(res.Rows, newAgingRow(row, detail))
+	}
+	for _, row := range r.Totals {
+		total := newAgingRow(row, false)
+		balance := r.Balances[row.Commodity]
+		total.Balance = balance.DecimalString(2)
+		res.Totals = append(res.Totals, total)
+	}
+	return res, nil
+}
+
+// writeAgingTable writes a row for each owner, with its items if detailed
+func writeAgingTable(w io.Writer, res *agingResult) {
+	fmt.Fprintf(w, "Aging of %s at %s\n\n", res.Type, res.Date)
+	fmt.Fprintf(w, "%s %-6s", StringPad("Owner", 30, " "), "Cur")
+	for _, label := range res.Labels {
+		fmt.Fprintf(w, " %12s", label)
+	}
+	fmt.Fprintf(w, " %12s\n", "Total")
+
+	row := func(name string, r agingRow) {
+		fmt.Fprintf(w, "%s %-6s", StringPad(name, 30, " "), r.Commodity)
+		for _, v := range r.Buckets {
+			fmt.Fprintf(w, " %12s", v)
+		}
+		fmt.Fprintf(w, " %12s\n", r.Total)
+	}
+	for _, r := range res.Rows {
+		name := r.Owner
+		if name == "" {
+			name = "(no owner)"
+		}
+		row(name, r)
+		for _, item := range r.Items {
+			invoice := item.Invoice
+			if invoice == "" {
+				invoice = "-"
+			}
+			fmt.Fprintf(w, "  %-10s due %s %4d days %12s %s\n", invoice, item.Due, item.Days, item.Amount, item.Account)
+		}
+	}
+	for _, r := range res.Totals {
+		row("Total", r)
+	}
+	fmt.Fprintln(w)
+	for _, r := range res.Totals {
+		fmt.Fprintf(w, "Account balance: %s %s\n", r.Balance, r.Commodity)
+	}
+	for _, s := range res.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", s)
+	}
+}
+
+func runAging(biz *business.Business, args []string) error {
+	fs := flag.NewFlagSet("aging", flag.ContinueOnError)
+	typ := fs.String("type", "receivable", "accounts: receivable (customers) or payable (vendors and employees)")
+	date := fs.String("date", "", "date of the report (default today)")
+	detail := fs.Bool("detail", false, "list the invoices and payments of each owner")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := aging(biz, *typ, *date, *detail)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		writeAgingTable(os.Stdout, res)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
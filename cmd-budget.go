@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdBudget = &command{
+	name:  "budget",
+	usage: "budget [-budget name] [-period n] [-format table|json]  (budget vs actual; period from 1, default all)",
+	run:   runBudget,
+}
+
+// budgetRow type: the JSON form of a row of the budget report
+type budgetRow struct {
+	Account     string   `json:"account"`
+	Level       int      `json:"level"`
+	Budget      string   `json:"budget"`
+	Actual      string   `json:"actual"`
+	Variance    string   `json:"variance"`
+	PercentUsed *float64 `json:"percent_used"` // null if no budget
+}
+
+// budgetResult type: the JSON form of the budget report
+type budgetResult struct {
+	Budget string      `json:"budget"`
+	Period int         `json:"period,omitempty"` // from 1, 0 for all the periods
+	From   string      `json:"from"`
+	To     string      `json:"to"` // last day, empty if open-ended
+	Rows   []budgetRow `json:"rows"`
+}
+
+// budgetReport returns the report of the named budget (the only one if
+// name is empty) for the period (from 1, 0 for all the periods)
+func budgetReport(book *model.Book, name string, period int) (*budgetResult, error) {
+	var b *model.Budget
+	switch {
+	case name != "":
+		if b = book.Budgets.ByName(name); b == nil {
+			return nil, fmt.Errorf("Budget not found: %s", name)
+		}
+	case len(book.Budgets) == 0:
+		return nil, errors.New("No budget found")
+	case len(book.Budgets) > 1:
+		names := make([]string, len(book.Budgets))
+		for j, b := range book.Budgets {
+			names[j] = b.Name
+		}
+		return nil, fmt.Errorf("Multiple budgets: use -budget with one of %s", strings.Join(names, ", "))
+	default:
+		b = book.Budgets[0]
+	}
+	if period < 0 || period > b.NumPeriods {
+		return nil, fmt.Errorf("Invalid period: %d (the budget has %d periods)", period, b.NumPeriods)
+	}
+
+	r := b.Report(book.Accounts)
+	res := &budgetResult{Budget: b.Name, Period: period, Rows: []budgetRow{}}
+	first, last := 0, b.NumPeriods-1
+	if period > 0 {
+		first, last = period-1, period-1
+	}
+	if b.NumPeriods > 0 {
+		res.From = r.Periods[first].Start.Format("2006-01-02")
+		if end := r.Periods[last].End; !end.IsZero() {
+			res.To = end.AddDate(0, 0, -1).Format("2006-01-02")
+		}
+	}
+
+	for _, row := range r.Rows {
+		budget, actual := row.Amounts(period - 1)
+		variance := row.Variance(period - 1)
+		br := budgetRow{
+			Account:  row.Account.FullName(),
+			Level:    row.Level,
+			Budget:   budget.DecimalString(2),
+			Actual:   actual.DecimalString(2),
+			Variance: variance.DecimalString(2),
+		}
+		if p, ok := row.PercentUsed(period - 1); ok {
+			br.PercentUsed = &p
+		}
+		res.Rows = append(res.Rows, br)
+	}
+	return res, nil
+}
+
+func runBudget(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("budget", flag.ContinueOnError)
+	name := fs.String("budget", "", "budget name (default the only budget)")
+	period := fs.Int("period", 0, "period number, from 1 (default all the periods)")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := budgetReport(book, *name, *period)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		fmt.Printf("%s: %s - %s\n\n", res.Budget, res.From, res.To)
+		fmt.Printf("%s %12s %12s %12s %7s\n", StringPad("Account", 40, " "), "Budget", "Actual", "Variance", "Used")
+		for _, row := range res.Rows {
+			used := ""
+			if row.PercentUsed != nil {
+				used = fmt.Sprintf("%.0f%%", *row.PercentUsed)
+			}
+			name := strings.Repeat("  ", row.Level-1) + row.Account[strings.LastIndex(row.Account, model.AccountSeparator)+1:]
+			fmt.Printf("%s %12s %12s %12s %7s\n", StringPad(name, 40, " "), row.Budget, row.Actual, row.Variance, used)
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
+
+// httpBudget is the handler of the /budget[?name=budget][&period=n] endpoint
+func httpBudget(srv *server, r *http.Request) (interface{}, error) {
+	period, err := formInt(r, "period", 0)
+	if err != nil {
+		return nil, err
+	}
+	return budgetReport(srv.book, r.FormValue("name"), period)
+}
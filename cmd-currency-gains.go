@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdCurrencyGains = &command{
+	name:  "currency-gains",
+	usage: "currency-gains [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-currency code] [-format table|json]  (realized exchange gains and losses)",
+	run:   runCurrencyGains,
+}
+
+// currencyGain type: the JSON form of a model.CurrencyGain
+type currencyGain struct {
+	Date        string `json:"date"`
+	Description string `json:"description"`
+	Currency    string `json:"currency"`
+	Quantity    string `json:"quantity"`
+	Proceeds    string `json:"proceeds"`
+	Basis       string `json:"basis"`
+	Gain        string `json:"gain"`
+}
+
+// currencyHolding type: the JSON form of a model.CurrencyHolding
+type currencyHolding struct {
+	Currency string `json:"currency"`
+	Quantity string `json:"quantity"`
+	Basis    string `json:"basis"`
+}
+
+// currencyGainsResult type: the JSON form of a model.CurrencyGainsReport
+type currencyGainsResult struct {
+	From     string            `json:"from,omitempty"`
+	To       string            `json:"to"`
+	Currency string            `json:"currency"`
+	Trading  bool              `json:"trading_accounts"`
+	Realized []currencyGain    `json:"realized"`
+	Total    string            `json:"total"`
+	Holdings []currencyHolding `json:"holdings"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// currencyGains computes the currency gains report from the day from
+// (empty for the beginning) to the day to (empty for today) in the
+// currency (empty for the book currency)
+func currencyGains(book *model.Book, from, to, currency string) (*currencyGainsResult, error) {
+	start, err := parseDate(from, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate(to, false)
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end = today()
+	}
+	if !start.IsZero() && end.Before(start) {
+		return nil, fmt.Errorf("Invalid period: %s > %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	r := book.CurrencyGains(start, end, currency)
+	total := r.Total()
+	res := &currencyGainsResult{
+		To:       end.Format("2006-01-02"),
+		Currency: r.Currency,
+		Trading:  book.UsesTradingAccounts(),
+		Realized: []currencyGain{},
+		Total:    total.FloatString(2),
+		Holdings: []currencyHolding{},
+		Warnings: r.Warnings,
+	}
+	if !start.IsZero() {
+		res.From = start.Format("2006-01-02")
+	}
+	for _, g := range r.Realized {
+		res.Realized = append(res.Realized, currencyGain{
+			Date:        g.Transaction.DatePosted.Format("2006-01-02"),
+			Description: g.Transaction.Description,
+			Currency:    g.Currency,
+			Quantity:    g.Quantity.DecimalString(2),
+			Proceeds:    g.Proceeds.FloatString(2),
+			Basis:       g.Basis.FloatString(2),
+			Gain:        g.Gain.FloatString(2),
+		})
+	}
+	for _, h := range r.Holdings {
+		res.Holdings = append(res.Holdings, currencyHolding{
+			Currency: h.Currency,
+			Quantity: h.Quantity.DecimalString(2),
+			Basis:    h.Basis.FloatString(2),
+		})
+	}
+	return res, nil
+}
+
+// writeCurrencyGainsTable writes the realized gains and the holdings
+func writeCurrencyGainsTable(w io.Writer, res *currencyGainsResult) {
+	from := res.From
+	if from == "" {
+		from = "the beginning"
+	}
+	fmt.Fprintf(w, "Currency gains in %s from %s to %s\n\n", res.Currency, from, res.To)
+	fmt.Fprintf(w, "%-10s %s %-4s %12s %12s %12s %12s\n", "Date", StringPad("Description", 30, " "), "Cur", "Quantity", "Proceeds", "Basis", "Gain")
+	for _, g := range res.Realized {
+		fmt.Fprintf(w, "%-10s %s %-4s %12s %12s %12s %12s\n", g.Date, StringPad(g.Description, 30, " "), g.Currency, g.Quantity, g.Proceeds, g.Basis, g.Gain)
+	}
+	fmt.Fprintf(w, "%s %12s\n", StringPad("Total", 85, " "), res.Total)
+
+	if len(res.Holdings) > 0 {
+		fmt.Fprintf(w, "\nHoldings at %s\n", res.To)
+		for _, h := range res.Holdings {
+			fmt.Fprintf(w, "%-4s %12s  basis %12s\n", h.Currency, h.Quantity, h.Basis)
+		}
+	}
+	for _, warning := range res.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+}
+
+func runCurrencyGains(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("currency-gains", flag.ContinueOnError)
+	from := fs.String("from", "", "first day of the realized gains (default the beginning)")
+	to := fs.String("to", "", "last day (default today)")
+	currency := fs.String("currency", "", "currency of the report (default the book currency)")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := currencyGains(book, *from, *to, *currency)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		writeCurrencyGainsTable(os.Stdout, res)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
+
+// httpCurrencyGains is the handler of the /currency-gains[?from=date][&to=date][&currency=code] endpoint
+func httpCurrencyGains(srv *server, r *http.Request) (interface{}, error) {
+	return currencyGains(srv.book, r.FormValue("from"), r.FormValue("to"), r.FormValue("currency"))
+}
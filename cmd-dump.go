@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/mmbros/gnucash-viewer/export"
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdDump = &command{
+	name:  "dump",
+	usage: "dump [-format json|yaml] [-o file]",
+	run:   runDump,
+}
+
+func runDump(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	format := fs.String("format", "json", "output format: json or yaml")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	w, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(book)
+	case "yaml":
+		return export.WriteYAML(w, book.Dump())
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mmbros/gnucash-viewer/export"
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+const exportUsage = `export csv [-what splits|register|tree] [-account name] [-delimiter c] [-date-format layout] [-decimal-separator s] [-precision n] [-italian] [-o file]
+  export ledger [-o file]
+  export beancount [-o file]
+  export qif|ofx -account name [-from date] [-to date] [-bank-id id] [-account-id id] [-o file]`
+
+var cmdExport = &command{
+	name:  "export",
+	usage: exportUsage,
+	run:   runExport,
+}
+
+// exporters is the map of the export formats
+var exporters = map[string]func(book *model.Book, args []string) error{
+	"csv":       exportCSV,
+	"ledger":    exportTo("ledger", export.WriteLedger),
+	"beancount": exportTo("beancount", export.WriteBeancount),
+	"qif":       exportStatement("qif", export.WriteQIF),
+	"ofx":       exportStatement("ofx", export.WriteOFX),
+}
+
+func runExport(book *model.Book, args []string) error {
+	if len(args) == 0 {
+		return errors.New("Missing export format")
+	}
+	exporter, ok := exporters[args[0]]
+	if !ok {
+		return fmt.Errorf("Unknown export format: %s", args[0])
+	}
+	return exporter(book, args[1:])
+}
+
+// createOutput returns stdout if path is empty, else the created file.
+func createOutput(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// findAccount returns the account with the given full name or name.
+func findAccount(book *model.Book, name string) (*model.Account, error) {
+	if acc := book.Accounts.ByFullName(name); acc != nil {
+		return acc, nil
+	}
+	if acc := book.Accounts.ByName(name); acc != nil {
+		return acc, nil
+	}
+	return nil, fmt.Errorf("Account not found: %s", name)
+}
+
+func exportCSV(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("export csv", flag.ContinueOnError)
+	what := fs.String("what", "splits", "what to export: splits, register or tree")
+	accountName := fs.String("account", "", "account full name or name (required by register)")
+	delimiter := fs.String("delimiter", ",", "field delimiter")
+	dateFormat := fs.String("date-format", "2006-01-02", "date layout")
+	decimalSep := fs.String("decimal-separator", ".", "decimal separator")
+	precision := fs.Int("precision", 2, "digits after the decimal separator")
+	italian := fs.Bool("italian", false, "use the Italian spreadsheet defaults, overridden by the flags set")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *what == "register" && *accountName == "" {
+		return errors.New("Missing -account for -what register")
+	}
+	if *precision < 0 {
+		return fmt.Errorf("Invalid precision: %d", *precision)
+	}
+	r, size := utf8.DecodeRuneInString(*delimiter)
+	if size != len(*delimiter) {
+		return fmt.Errorf("Invalid delimiter: %q", *delimiter)
+	}
+
+	// the defaults, or the Italian ones, with the flags set on top
+	opts := export.CSVOptions{}
+	if *italian {
+		opts = export.ItalianCSVOptions
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "delimiter":
+			opts.Delimiter = r
+		case "date-format":
+			opts.DateFormat = *dateFormat
+		case "decimal-separator":
+			opts.DecimalSeparator = *decimalSep
+		case "precision":
+			opts.Precision = precision
+		}
+	})
+
+	w, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	switch *what {
+	case "splits":
+		return export.WriteSplitsCSV(w, book, &opts)
+	case "register":
+		acc, err := findAccount(book, *accountName)
+		if err != nil {
+			return err
+		}
+		return export.WriteRegisterCSV(w, acc, &opts)
+	case "tree":
+		return export.WriteAccountTreeCSV(w, book.Accounts, &opts)
+	}
+	return fmt.Errorf("Invalid -what value: %s", *what)
+}
+
+// exportTo returns an exporter of the whole book that accepts only the
+// output file option.
+func exportTo(name string, write func(w io.Writer, book *model.Book) error) func(book *model.Book, args []string) error {
+	return func(book *model.Book, args []string) error {
+		fs := flag.NewFlagSet("export "+name, flag.ContinueOnError)
+		output := fs.String("o", "", "output file (default stdout)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		w, err := createOutput(*output)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+
+		return write(w, book)
+	}
+}
+
+// exportStatement returns an exporter of the statement of an account.
+func exportStatement(name string, write func(w io.Writer, account *model.Account, opts *export.StatementOptions) error) func(book *model.Book, args []string) error {
+	return func(book *model.Book, args []string) error {
+		fs := flag.NewFlagSet("export "+name, flag.ContinueOnError)
+		accountName := fs.String("account", "", "account full name or name")
+		from := fs.String("from", "", "first date (YYYY-MM-DD)")
+		to := fs.String("to", "", "last date (YYYY-MM-DD)")
+		bankID := fs.String("bank-id", "", "bank identifier (OFX only)")
+		accountID := fs.String("account-id", "", "account number (OFX only)")
+		output := fs.String("o", "", "output file (default stdout)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		acc, err := findAccount(book, *accountName)
+		if err != nil {
+			return err
+		}
+		opts := export.StatementOptions{BankID: *bankID, AccountID: *accountID}
+		if opts.From, err = parseDate(*from, false); err != nil {
+			return err
+		}
+		if opts.To, err = parseDate(*to, true); err != nil {
+			return err
+		}
+
+		w, err := createOutput(*output)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+
+		return write(w, acc, &opts)
+	}
+}
+
+// parseDate parses a date in the YYYY-MM-DD format. The empty string is
+// the zero time. If endOfDay is true, the last instant of the day is returned.
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("Invalid date: %s", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
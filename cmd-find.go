@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/index"
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdFind = &command{
+	name:  "find",
+	usage: "find [-limit n] words  (full-text search of descriptions, memos and notes)",
+	run:   runFind,
+}
+
+// findResult type: a transaction found by the full-text index
+type findResult struct {
+	Date          string `json:"date"`
+	TransactionID string `json:"transaction_id"`
+	Description   string `json:"description"`
+}
+
+// limitTransactions returns the first limit transactions (all if limit <= 0)
+func limitTransactions(list model.Transactions, limit int) model.Transactions {
+	if limit > 0 && len(list) > limit {
+		return list[:limit]
+	}
+	return list
+}
+
+func runFind(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("find", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "max number of results (0 no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	list := index.New(book).Search(strings.Join(fs.Args(), " "))
+	for _, t := range limitTransactions(list, *limit) {
+		fmt.Printf("%s %s %s\n", t.DatePosted.Format("2006-01-02"), t.ID, t.Description)
+	}
+	fmt.Printf("\ntransactions found: %d\n", len(list))
+	return nil
+}
+
+// httpFind is the handler of the /find?q=words[&limit=n] endpoint
+func httpFind(srv *server, r *http.Request) (interface{}, error) {
+	limit, err := formInt(r, "limit", 0)
+	if err != nil {
+		return nil, err
+	}
+	res := []findResult{}
+	for _, t := range limitTransactions(srv.index.Search(r.FormValue("q")), limit) {
+		res = append(res, findResult{
+			Date:          t.DatePosted.Format("2006-01-02"),
+			TransactionID: t.ID,
+			Description:   t.Description,
+		})
+	}
+	return res, nil
+}
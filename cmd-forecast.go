@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdForecast = &command{
+	name:  "forecast",
+	usage: "forecast [-from YYYY-MM-DD] [-to YYYY-MM-DD | -days n] [-account name]... [-format table|csv|json] [-o file]",
+	run:   runForecast,
+}
+
+// forecastAccountTypes are the types of the accounts forecasted by default
+var forecastAccountTypes = map[string]bool{"BANK": true, "CASH": true, "CREDIT": true}
+
+// stringList type: a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ", ") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// forecastPoint type: a point of the chart-ready series of an account
+type forecastPoint struct {
+	Date    string `json:"date"`
+	Change  string `json:"change"`
+	Balance string `json:"balance"`
+}
+
+// forecastAccount type: the JSON form of a model.AccountForecast
+type forecastAccount struct {
+	Account      string          `json:"account"`
+	Type         string          `json:"type"`
+	StartBalance string          `json:"start_balance"`
+	MinBalance   string          `json:"min_balance"`
+	MinDate      string          `json:"min_date"`
+	NegativeDate string          `json:"negative_date,omitempty"`
+	Series       []forecastPoint `json:"series"`
+}
+
+// forecastResult type: the JSON form of a model.Forecast
+type forecastResult struct {
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Accounts []forecastAccount `json:"accounts"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+func newForecastResult(f *model.Forecast) *forecastResult {
+	res := &forecastResult{
+		From:     f.From.Format("2006-01-02"),
+		To:       f.To.Format("2006-01-02"),
+		Accounts: []forecastAccount{},
+		Warnings: f.Warnings,
+	}
+	for _, af := range f.Accounts {
+		a := forecastAccount{
+			Account:      af.Account.FullName(),
+			Type:         af.Account.Type.Code(),
+			StartBalance: af.StartBalance.DecimalString(2),
+			MinBalance:   af.MinBalance.DecimalString(2),
+			MinDate:      af.MinDate.Format("2006-01-02"),
+			Series:       []forecastPoint{},
+		}
+		if !af.NegativeDate.IsZero() {
+			a.NegativeDate = af.NegativeDate.Format("2006-01-02")
+		}
+		for _, p := range af.Points {
+			a.Series = append(a.Series, forecastPoint{
+				Date:    p.Date.Format("2006-01-02"),
+				Change:  p.Change.DecimalString(2),
+				Balance: p.Balance.DecimalString(2),
+			})
+		}
+		res.Accounts = append(res.Accounts, a)
+	}
+	return res
+}
+
+// forecast computes the forecast of the named accounts (the BANK, CASH
+// and CREDIT accounts if none) from the day from to the day to, or for
+// the given number of days if to is empty.
+func forecast(book *model.Book, names []string, from, to string, days int) (*model.Forecast, error) {
+	start, err := parseDate(from, false)
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		start = today()
+	}
+	end, err := parseDate(to, false)
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end = start.AddDate(0, 0, days)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("Invalid period: %s > %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	var accounts []*model.Account
+	for _, name := range names {
+		a, err := findAccount(book, name)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	if len(accounts) == 0 {
+		book.Accounts.Walk(func(a *model.Account, level int) {
+			if forecastAccountTypes[a.Type.Code()] && !a.Placeholder() {
+				accounts = append(accounts, a)
+			}
+		})
+	}
+	return book.Forecast(start, end, accounts), nil
+}
+
+// writeForecastTable writes the days with changes of each account
+func writeForecastTable(w io.Writer, f *model.Forecast) {
+	for _, af := range f.Accounts {
+		fmt.Fprintf(w, "%s\n", af.Account.FullName())
+		fmt.Fprintf(w, "  %-10s %12s %12s\n", f.From.Format("2006-01-02"), "", af.StartBalance.DecimalString(2))
+		for _, p := range af.Points {
+			if p.Change.Sign() == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "  %-10s %12s %12s\n", p.Date.Format("2006-01-02"), p.Change.DecimalString(2), p.Balance.DecimalString(2))
+		}
+		fmt.Fprintf(w, "  minimum balance %s on %s\n", af.MinBalance.DecimalString(2), af.MinDate.Format("2006-01-02"))
+		if !af.NegativeDate.IsZero() {
+			fmt.Fprintf(w, "  WARNING: negative balance from %s\n", af.NegativeDate.Format("2006-01-02"))
+		}
+		fmt.Fprintln(w)
+	}
+	for _, warning := range f.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+}
+
+// writeForecastCSV writes a row for each day with the balance of each account
+func writeForecastCSV(w io.Writer, f *model.Forecast) error {
+	cw := csv.NewWriter(w)
+	header := []string{"date"}
+	for _, af := range f.Accounts {
+		header = append(header, af.Account.FullName())
+	}
+	cw.Write(header)
+	if len(f.Accounts) > 0 {
+		for j, p := range f.Accounts[0].Points {
+			row := []string{p.Date.Format("2006-01-02")}
+			for _, af := range f.Accounts {
+				row = append(row, af.Points[j].Balance.DecimalString(2))
+			}
+			cw.Write(row)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func runForecast(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("forecast", flag.ContinueOnError)
+	from := fs.String("from", "", "first day (default today)")
+	to := fs.String("to", "", "last day (default from + days)")
+	days := fs.Int("days", 90, "number of days, if -to is not given")
+	var names stringList
+	fs.Var(&names, "account", "account full name or name (repeatable; default the BANK, CASH and CREDIT accounts)")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := forecast(book, names, *from, *to, *days)
+	if err != nil {
+		return err
+	}
+
+	w, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	switch *format {
+	case "table":
+		writeForecastTable(w, f)
+		return nil
+	case "csv":
+		return writeForecastCSV(w, f)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newForecastResult(f))
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
+
+// httpForecast is the handler of the /forecast[?from=date][&to=date][&days=n][&account=name...] endpoint
+func httpForecast(srv *server, r *http.Request) (interface{}, error) {
+	days, err := formInt(r, "days", 90)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	f, err := forecast(srv.book, r.Form["account"], r.FormValue("from"), r.FormValue("to"), days)
+	if err != nil {
+		return nil, err
+	}
+	return newForecastResult(f), nil
+}
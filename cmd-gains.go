@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdGains = &command{
+	name:  "gains",
+	usage: "gains [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-method fifo|lifo|average] [-lots] [-format table|json]  (capital gains)",
+	run:   runGains,
+}
+
+// realizedGain type: the JSON form of a model.RealizedGain
+type realizedGain struct {
+	Account  string `json:"account"`
+	Lot      string `json:"lot,omitempty"`
+	Acquired string `json:"acquired"`
+	Sold     string `json:"sold"`
+	Quantity string `json:"quantity"`
+	Proceeds string `json:"proceeds"`
+	Basis    string `json:"basis"`
+	Gain     string `json:"gain"`
+	Term     string `json:"term"`
+}
+
+// unrealizedGain type: the JSON form of a model.UnrealizedGain
+type unrealizedGain struct {
+	Account  string `json:"account"`
+	Lot      string `json:"lot,omitempty"`
+	Acquired string `json:"acquired"`
+	Quantity string `json:"quantity"`
+	Basis    string `json:"basis"`
+	Price    string `json:"price"`
+	Value    string `json:"value"`
+	Gain     string `json:"gain"`
+	Term     string `json:"term"`
+	Source   string `json:"price_source"`
+}
+
+// lotResult type: the JSON form of a model.Lot
+type lotResult struct {
+	Account  string `json:"account"`
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Opened   string `json:"opened"`
+	Quantity string `json:"quantity"`
+	Basis    string `json:"basis"`
+	Closed   bool   `json:"closed"`
+}
+
+// gainsResult type: the JSON form of a model.GainsReport
+type gainsResult struct {
+	From       string           `json:"from,omitempty"`
+	To         string           `json:"to"`
+	Method     string           `json:"method"`
+	Realized   []realizedGain   `json:"realized"`
+	ShortTerm  string           `json:"short_term"` // total of the realized gains
+	LongTerm   string           `json:"long_term"`  // total of the realized gains
+	Unrealized []unrealizedGain `json:"unrealized"`
+	Lots       []lotResult      `json:"lots,omitempty"`
+	Warnings   []string         `json:"warnings,omitempty"`
+}
+
+// term returns the label of the holding period
+func term(longTerm bool) string {
+	if longTerm {
+		return "long"
+	}
+	return "short"
+}
+
+// lotTitle returns the title, or the ID if untitled, of the lot (empty if nil)
+func lotTitle(lot *model.Lot) string {
+	if lot == nil {
+		return ""
+	}
+	if title := lot.Title(); title != "" {
+		return title
+	}
+	return lot.ID
+}
+
+// gains computes the capital gains report from the day from (empty
+// for the beginning) to the day to (empty for today)
+func gains(book *model.Book, from, to, method string, lots bool) (*gainsResult, error) {
+	start, err := parseDate(from, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate(to, false)
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end = today()
+	}
+	if !start.IsZero() && end.Before(start) {
+		return nil, fmt.Errorf("Invalid period: %s > %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	r, err := book.CapitalGains(start, end, method)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &gainsResult{
+		To:         end.Format("2006-01-02"),
+		Method:     method,
+		Realized:   []realizedGain{},
+		Unrealized: []unrealizedGain{},
+		Warnings:   r.Warnings,
+	}
+	short, long := r.Totals()
+	res.ShortTerm, res.LongTerm = short.FloatString(2), long.FloatString(2)
+	if !start.IsZero() {
+		res.From = start.Format("2006-01-02")
+	}
+	for _, g := range r.Realized {
+		res.Realized = append(res.Realized, realizedGain{
+			Account:  g.Account.FullName(),
+			Lot:      lotTitle(g.Lot),
+			Acquired: g.Acquired.Format("2006-01-02"),
+			Sold:     g.Sale.Transaction.DatePosted.Format("2006-01-02"),
+			Quantity: g.Quantity.DecimalString(4),
+			Proceeds: g.Proceeds.FloatString(2),
+			Basis:    g.Basis.FloatString(2),
+			Gain:     g.Gain.FloatString(2),
+			Term:     term(g.LongTerm),
+		})
+	}
+	for _, g := range r.Unrealized {
+		res.Unrealized = append(res.Unrealized, unrealizedGain{
+			Account:  g.Account.FullName(),
+			Lot:      lotTitle(g.Lot),
+			Acquired: g.Acquired.Format("2006-01-02"),
+			Quantity: g.Quantity.DecimalString(4),
+			Basis:    g.Basis.FloatString(2),
+			Price:    g.Price.FloatString(4),
+			Value:    g.Value.FloatString(2),
+			Gain:     g.Gain.FloatString(2),
+			Term:     term(g.LongTerm),
+			Source:   g.Source,
+		})
+	}
+	if lots {
+		book.Accounts.Walk(func(a *model.Account, level int) {
+			for _, lot := range a.Lots {
+				q, basis := lot.Quantity(), lot.Basis()
+				res.Lots = append(res.Lots, lotResult{
+					Account:  a.FullName(),
+					ID:       lot.ID,
+					Title:    lot.Title(),
+					Opened:   lot.Opened().Format("2006-01-02"),
+					Quantity: q.DecimalString(4),
+					Basis:    basis.FloatString(2),
+					Closed:   lot.Closed(),
+				})
+			}
+		})
+	}
+	return res, nil
+}
+
+// writeGainsTable writes the gains report as text tables
+func writeGainsTable(w io.Writer, res *gainsResult) {
+	from := res.From
+	if from == "" {
+		from = "beginning"
+	}
+	fmt.Fprintf(w, "Realized gains (%s - %s, %s)\n", from, res.To, res.Method)
+	fmt.Fprintf(w, "%s %-10s %-10s %12s %12s %12s %12s %-5s\n", StringPad("Account", 30, " "), "Acquired", "Sold", "Quantity", "Proceeds", "Basis", "Gain", "Term")
+	for _, g := range res.Realized {
+		fmt.Fprintf(w, "%s %-10s %-10s %12s %12s %12s %12s %-5s\n", StringPad(g.Account, 30, " "), g.Acquired, g.Sold, g.Quantity, g.Proceeds, g.Basis, g.Gain, g.Term)
+	}
+	fmt.Fprintf(w, "Short term: %s  Long term: %s\n", res.ShortTerm, res.LongTerm)
+
+	fmt.Fprintf(w, "\nUnrealized gains (%s)\n", res.To)
+	fmt.Fprintf(w, "%s %-10s %12s %12s %12s %12s %12s %-5s\n", StringPad("Account", 30, " "), "Acquired", "Quantity", "Basis", "Price", "Value", "Gain", "Term")
+	for _, g := range res.Unrealized {
+		fmt.Fprintf(w, "%s %-10s %12s %12s %12s %12s %12s %-5s\n", StringPad(g.Account, 30, " "), g.Acquired, g.Quantity, g.Basis, g.Price, g.Value, g.Gain, g.Term)
+	}
+
+	if len(res.Lots) > 0 {
+		fmt.Fprintf(w, "\nLots\n")
+		fmt.Fprintf(w, "%s %s %-10s %12s %12s %s\n", StringPad("Account", 30, " "), StringPad("Lot", 20, " "), "Opened", "Quantity", "Basis", "Status")
+		for _, lot := range res.Lots {
+			status := "open"
+			if lot.Closed {
+				status = "closed"
+			}
+			title := lot.Title
+			if title == "" {
+				title = lot.ID
+			}
+			fmt.Fprintf(w, "%s %s %-10s %12s %12s %s\n", StringPad(lot.Account, 30, " "), StringPad(title, 20, " "), lot.Opened, lot.Quantity, lot.Basis, status)
+		}
+	}
+
+	for _, warning := range res.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+}
+
+func runGains(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("gains", flag.ContinueOnError)
+	from := fs.String("from", "", "first day of the realized gains (default the beginning)")
+	to := fs.String("to", "", "last day (default today)")
+	method := fs.String("method", model.MethodFIFO, "cost basis method of the sales without lot: fifo, lifo or average")
+	lots := fs.Bool("lots", false, "list the lots of the accounts")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := gains(book, *from, *to, *method, *lots)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		writeGainsTable(os.Stdout, res)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
+
+// httpGains is the handler of the /gains[?from=date][&to=date][&method=fifo|lifo|average][&lots=1] endpoint
+func httpGains(srv *server, r *http.Request) (interface{}, error) {
+	method := r.FormValue("method")
+	if method == "" {
+		method = model.MethodFIFO
+	}
+	return gains(srv.book, r.FormValue("from"), r.FormValue("to"), method, r.FormValue("lots") != "")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/mmbros/gnucash-viewer/importer"
+	"github.com/mmbros/gnucash-viewer/model"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+const importUsage = `import -account name [-format csv|ofx] [-rules file] [-bayes-threshold p] [-default-account name]
+         [-delimiter c] [-date-format layout] [-decimal-separator s] [-thousands-separator s] [-skip n]
+         [-date-col n] [-desc-col n] [-amount-col n] [-debit-col n] [-credit-col n] [-memo-col n] [-id-col n]
+         [-o file [-force]] statement-file`
+
+var cmdImport = &command{
+	name:  "import",
+	usage: importUsage,
+	run:   runImport,
+}
+
+// readStatement reads the statement file in the given format
+func readStatement(path, format string, csvFormat *importer.CSVFormat) (*importer.Statement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch format {
+	case "csv":
+		return importer.ReadCSV(f, csvFormat)
+	case "ofx":
+		return importer.ReadOFX(f)
+	}
+	return nil, fmt.Errorf("Invalid statement format: %s", format)
+}
+
+// csvFormatFlags defines the flags of the CSV statement format
+func csvFormatFlags(fs *flag.FlagSet) func() (*importer.CSVFormat, error) {
+	def := importer.DefaultCSVFormat
+	delimiter := fs.String("delimiter", string(def.Delimiter), "CSV field delimiter")
+	dateFormat := fs.String("date-format", def.DateFormat, "CSV date layout")
+	decimalSep := fs.String("decimal-separator", def.DecimalSeparator, "CSV decimal separator")
+	thousandsSep := fs.String("thousands-separator", def.ThousandsSeparator, "CSV thousands separator")
+	skip := fs.Int("skip", def.Skip, "CSV header lines")
+	dateCol := fs.Int("date-col", def.DateColumn, "CSV date column")
+	descCol := fs.Int("desc-col", def.DescriptionColumn, "CSV description column")
+	amountCol := fs.Int("amount-col", def.AmountColumn, "CSV amount column (0 to use debit and credit columns)")
+	debitCol := fs.Int("debit-col", def.DebitColumn, "CSV debit column")
+	creditCol := fs.Int("credit-col", def.CreditColumn, "CSV credit column")
+	memoCol := fs.Int("memo-col", def.MemoColumn, "CSV memo column")
+	idCol := fs.Int("id-col", def.IDColumn, "CSV transaction ID column")
+
+	return func() (*importer.CSVFormat, error) {
+		r, size := utf8.DecodeRuneInString(*delimiter)
+		if size != len(*delimiter) {
+			return nil, fmt.Errorf("Invalid delimiter: %q", *delimiter)
+		}
+		return &importer.CSVFormat{
+			Delimiter:          r,
+			DateFormat:         *dateFormat,
+			DecimalSeparator:   *decimalSep,
+			ThousandsSeparator: *thousandsSep,
+			Skip:               *skip,
+			DateColumn:         *dateCol,
+			DescriptionColumn:  *descCol,
+			AmountColumn:       *amountCol,
+			DebitColumn:        *debitCol,
+			CreditColumn:       *creditCol,
+			MemoColumn:         *memoCol,
+			IDColumn:           *idCol,
+		}, nil
+	}
+}
+
+func runImport(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	accountName := fs.String("account", "", "account of the statement")
+	format := fs.String("format", "csv", "statement format: csv or ofx")
+	rulesPath := fs.String("rules", "", "rules file (JSON)")
+	defaultName := fs.String("default-account", "", "account of the unmatched lines")
+	threshold := fs.Float64("bayes-threshold", 0, "minimum probability of the import-map-bayes match (0-1)")
+	output := fs.String("o", "", "write the book with the new transactions to file (can be the GnuCash file itself)")
+	force := fs.Bool("force", false, "write the file even if locked by GnuCash")
+	csvFormat := csvFormatFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("Missing statement file")
+	}
+
+	imp := &importer.Importer{Book: book, BayesThreshold: *threshold}
+	var err error
+	if imp.Account, err = findAccount(book, *accountName); err != nil {
+		return err
+	}
+	if *defaultName != "" {
+		if imp.DefaultAccount, err = findAccount(book, *defaultName); err != nil {
+			return err
+		}
+	}
+	if *rulesPath != "" {
+		f, err := os.Open(*rulesPath)
+		if err != nil {
+			return err
+		}
+		imp.Rules, err = importer.ReadRules(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	cf, err := csvFormat()
+	if err != nil {
+		return err
+	}
+	st, err := readStatement(fs.Arg(0), *format, cf)
+	if err != nil {
+		return err
+	}
+
+	entries, err := imp.Match(st)
+	if err != nil {
+		return err
+	}
+	var unmatched int
+	for _, e := range entries {
+		var target string
+		switch {
+		case e.Duplicate != nil:
+			target = "DUPLICATE"
+		case e.Account == nil:
+			target = "UNMATCHED"
+			unmatched++
+		default:
+			target = fmt.Sprintf("%s (%s)", e.Account.FullName(), e.MatchedBy)
+		}
+		fmt.Printf("%s %10s %s -> %s\n",
+			e.Line.Date.Format("2006-01-02"),
+			e.Line.Amount.FloatString(2),
+			StringPad(e.Line.Description, 40, " "),
+			target)
+	}
+
+	transactions, err := imp.Transactions(entries)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nlines: %d, new transactions: %d, unmatched: %d\n", len(entries), len(transactions), unmatched)
+
+	if *output == "" {
+		return nil
+	}
+	f, err := gncxml.ReadRawFile(*gnucashPath)
+	if err != nil {
+		return err
+	}
+	if err := f.AddTransactions(book.ID, transactions); err != nil {
+		return err
+	}
+	return f.WriteFile(*output, *force)
+}
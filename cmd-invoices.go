@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/business"
+)
+
+var cmdInvoices = &command{
+	name:        "invoices",
+	usage:       "invoices [-type customer|vendor|employee] [-owner name] [-date YYYY-MM-DD] [-all] [-format table|json]  (open invoices and bills per owner)",
+	runBusiness: runInvoices,
+}
+
+// ownerTypes maps the -type values to the business owner types
+var ownerTypes = map[string]string{
+	"":         "",
+	"customer": business.OwnerCustomer,
+	"vendor":   business.OwnerVendor,
+	"employee": business.OwnerEmployee,
+}
+
+// invoiceResult type: the JSON form of a business.Invoice
+type invoiceResult struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Job       string `json:"job,omitempty"`
+	BillingID string `json:"billing_id,omitempty"`
+	Opened    string `json:"opened"`
+	Posted    string `json:"posted,omitempty"`
+	Due       string `json:"due,omitempty"`
+	Account   string `json:"account,omitempty"`
+	Total     string `json:"total"`
+	Balance   string `json:"balance"`
+}
+
+// ownerResult type: the invoices of an owner
+type ownerResult struct {
+	Type     string          `json:"type"`
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Balance  string          `json:"balance"`
+	Invoices []invoiceResult `json:"invoices"`
+}
+
+// invoices returns the invoices grouped by owner: the ones open at the
+// date if all is false, else all the invoices
+func invoices(biz *business.Business, ownerType, ownerName string, date string, all bool) ([]*ownerResult, error) {
+	t, ok := ownerTypes[ownerType]
+	if !ok {
+		return nil, fmt.Errorf("Invalid owner type: %s", ownerType)
+	}
+	at, err := parseDate(date, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []*business.Owner
+	if ownerName != "" {
+		o := biz.Owner(ownerName)
+		if o == nil {
+			return nil, fmt.Errorf("Owner not found: %s", ownerName)
+		}
+		owners = append(owners, o)
+	} else {
+		owners = biz.Owners()
+	}
+
+	list := []*ownerResult{}
+	for _, o := range owners {
+		if t != "" && o.Type != t {
+			continue
+		}
+		invs := o.Invoices
+		if !all {
+			invs = o.OpenInvoices(at)
+		}
+		if len(invs) == 0 {
+			continue
+		}
+		balance := o.Balance(at)
+		res := &ownerResult{
+			Type:    o.TypeLabel(),
+			ID:      o.ID,
+			Name:    o.Name,
+			Balance: balance.DecimalString(2),
+		}
+		for _, inv := range invs {
+			res.Invoices = append(res.Invoices, newInvoiceResult(inv, at))
+		}
+		list = append(list, res)
+	}
+	return list, nil
+}
+
+func newInvoiceResult(inv *business.Invoice, at time.Time) invoiceResult {
+	total, balance := inv.Total(), inv.Balance(at)
+	res := invoiceResult{
+		ID:        inv.ID,
+		Type:      inv.Type(),
+		BillingID: inv.BillingID,
+		Opened:    inv.Opened.Format("2006-01-02"),
+		Total:     total.DecimalString(2),
+		Balance:   balance.DecimalString(2),
+	}
+	if inv.Job != nil {
+		res.Job = inv.Job.Name
+	}
+	if inv.IsPosted() {
+		res.Posted = inv.Posted.Format("2006-01-02")
+		res.Due = inv.DueDate().Format("2006-01-02")
+		res.Account = inv.Account.FullName()
+	}
+	return res
+}
+
+// writeInvoicesTable writes the invoices of each owner with the owner balance
+func writeInvoicesTable(w io.Writer, list []*ownerResult) {
+	for _, o := range list {
+		fmt.Fprintf(w, "%s %s (%s)\n", o.Type, o.Name, o.ID)
+		fmt.Fprintf(w, "  %-10s %-8s %-10s %-10s %12s %12s\n", "ID", "Type", "Posted", "Due", "Total", "Balance")
+		for _, inv := range o.Invoices {
+			fmt.Fprintf(w, "  %-10s %-8s %-10s %-10s %12s %12s\n", inv.ID, inv.Type, inv.Posted, inv.Due, inv.Total, inv.Balance)
+		}
+		fmt.Fprintf(w, "  %s %12s\n\n", StringPad("Balance", 53, " "), o.Balance)
+	}
+}
+
+func runInvoices(biz *business.Business, args []string) error {
+	fs := flag.NewFlagSet("invoices", flag.ContinueOnError)
+	ownerType := fs.String("type", "", "owner type: customer, vendor or employee (default all)")
+	owner := fs.String("owner", "", "owner name or ID (default all)")
+	date := fs.String("date", "", "balances at the end of the day (default all the payments)")
+	all := fs.Bool("all", false, "list all the invoices, also paid or not posted")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	list, err := invoices(biz, *ownerType, *owner, *date, *all)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		writeInvoicesTable(os.Stdout, list)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
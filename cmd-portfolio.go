@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdPortfolio = &command{
+	name:  "portfolio",
+	usage: "portfolio [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-format table|json]  (holdings and returns of the investment accounts)",
+	run:   runPortfolio,
+}
+
+// returns type: the JSON form of the returns of a holding or group, in percent
+type returns struct {
+	MoneyWeighted *float64 `json:"money_weighted"` // annualized (XIRR), null if not computable
+	TimeWeighted  *float64 `json:"time_weighted"`  // of the period, null if not computable
+}
+
+// portfolioHolding type: the JSON form of a model.PortfolioHolding
+type portfolioHolding struct {
+	Account     string  `json:"account"`
+	Commodity   string  `json:"commodity"`
+	Currency    string  `json:"currency"`
+	Shares      string  `json:"shares"`
+	AvgCost     string  `json:"avg_cost"`
+	Cost        string  `json:"cost"`
+	Price       string  `json:"price,omitempty"`
+	PriceDate   string  `json:"price_date,omitempty"`
+	PriceSource string  `json:"price_source,omitempty"`
+	Value       string  `json:"value,omitempty"` // empty if the commodity has no price
+	Gain        string  `json:"gain,omitempty"`  // empty if the commodity has no price
+	Returns     returns `json:"returns"`
+}
+
+// portfolioGroup type: the JSON form of a model.PortfolioGroup
+type portfolioGroup struct {
+	Account  string             `json:"account,omitempty"`
+	Holdings []portfolioHolding `json:"holdings,omitempty"`
+	Cost     string             `json:"cost"`
+	Value    string             `json:"value"`
+	Gain     string             `json:"gain"`
+	Returns  returns            `json:"returns"`
+}
+
+// portfolioResult type: the JSON form of a model.Portfolio
+type portfolioResult struct {
+	From     string           `json:"from,omitempty"`
+	To       string           `json:"to"`
+	Groups   []portfolioGroup `json:"groups"`
+	Total    portfolioGroup   `json:"total"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// performer is implemented by the holdings and groups of a portfolio
+type performer interface {
+	MoneyWeightedReturn() (float64, bool)
+	TimeWeightedReturn() (float64, bool)
+}
+
+func newReturns(p performer) returns {
+	var r returns
+	if v, ok := p.MoneyWeightedReturn(); ok {
+		v *= 100
+		r.MoneyWeighted = &v
+	}
+	if v, ok := p.TimeWeightedReturn(); ok {
+		v *= 100
+		r.TimeWeighted = &v
+	}
+	return r
+}
+
+func newPortfolioGroup(g *model.PortfolioGroup) portfolioGroup {
+	res := portfolioGroup{
+		Cost:    g.Cost.FloatString(2),
+		Value:   g.Value.FloatString(2),
+		Gain:    g.Gain.FloatString(2),
+		Returns: newReturns(g),
+	}
+	if g.Account != nil {
+		res.Account = g.Account.FullName()
+	}
+	for _, h := range g.Holdings {
+		ph := portfolioHolding{
+			Account:   h.Account.FullName(),
+			Commodity: h.Account.Currency,
+			Currency:  h.Currency,
+			Shares:    h.Shares.DecimalString(4),
+			AvgCost:   h.AvgCost.FloatString(4),
+			Cost:      h.Cost.FloatString(2),
+			Returns:   newReturns(h),
+		}
+		if h.Valued() {
+			ph.Value = h.Value.FloatString(2)
+			ph.Gain = h.Gain.FloatString(2)
+		}
+		if h.Price != nil {
+			ph.Price = h.Price.Value.FloatString(4)
+			ph.PriceDate = h.Price.Time.Format("2006-01-02")
+			ph.PriceSource = h.Price.Source
+		}
+		res.Holdings = append(res.Holdings, ph)
+	}
+	return res
+}
+
+// portfolio returns the portfolio at the day to (empty for today) with
+// the returns from the day from (empty for the beginning)
+func portfolio(book *model.Book, from, to string) (*portfolioResult, error) {
+	start, err := parseDate(from, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate(to, false)
+	if err != nil {
+		return nil, err
+	}
+	if end.IsZero() {
+		end = today()
+	}
+	if !start.IsZero() && end.Before(start) {
+		return nil, fmt.Errorf("Invalid period: %s > %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	pf, err := book.Portfolio(start, end)
+	if err != nil {
+		return nil, err
+	}
+	res := &portfolioResult{
+		To:       end.Format("2006-01-02"),
+		Groups:   []portfolioGroup{},
+		Total:    newPortfolioGroup(pf.Total),
+		Warnings: pf.Warnings,
+	}
+	res.Total.Holdings = nil
+	if !start.IsZero() {
+		res.From = start.Format("2006-01-02")
+	}
+	for _, g := range pf.Groups {
+		res.Groups = append(res.Groups, newPortfolioGroup(g))
+	}
+	return res, nil
+}
+
+// percent returns the percentage, or "-" if nil
+func percent(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *v)
+}
+
+// writePortfolioTable writes the holdings grouped by parent account
+func writePortfolioTable(w io.Writer, res *portfolioResult) {
+	from := res.From
+	if from == "" {
+		from = "beginning"
+	}
+	fmt.Fprintf(w, "Portfolio at %s, returns from %s\n\n", res.To, from)
+	fmt.Fprintf(w, "%s %12s %12s %12s %12s %12s %12s %9s %9s\n", StringPad("Account", 30, " "), "Shares", "Avg cost", "Price", "Cost", "Value", "Gain", "MWR", "TWR")
+	row := func(name, shares, avgCost, price, cost, value, gain string, r returns) {
+		fmt.Fprintf(w, "%s %12s %12s %12s %12s %12s %12s %9s %9s\n", StringPad(name, 30, " "), shares, avgCost, price, cost, value, gain,
+			percent(r.MoneyWeighted), percent(r.TimeWeighted))
+	}
+	for _, g := range res.Groups {
+		fmt.Fprintf(w, "%s\n", g.Account)
+		for _, h := range g.Holdings {
+			value, gain := h.Value, h.Gain
+			if value == "" {
+				value, gain = "-", "-"
+			}
+			row("  "+h.Account, h.Shares, h.AvgCost, h.Price, h.Cost, value, gain, h.Returns)
+		}
+		row("  Total "+g.Account, "", "", "", g.Cost, g.Value, g.Gain, g.Returns)
+		fmt.Fprintln(w)
+	}
+	row("Total", "", "", "", res.Total.Cost, res.Total.Value, res.Total.Gain, res.Total.Returns)
+	for _, warning := range res.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+}
+
+func runPortfolio(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("portfolio", flag.ContinueOnError)
+	from := fs.String("from", "", "first day of the returns (default the beginning)")
+	to := fs.String("to", "", "day of the holdings (default today)")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	res, err := portfolio(book, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		writePortfolioTable(os.Stdout, res)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
+
+// httpPortfolio is the handler of the /portfolio[?from=date][&to=date] endpoint
+func httpPortfolio(srv *server, r *http.Request) (interface{}, error) {
+	return portfolio(srv.book, r.FormValue("from"), r.FormValue("to"))
+}
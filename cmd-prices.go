@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+var cmdPrices = &command{
+	name:  "prices",
+	usage: "prices [-commodity id [-currency id] [-from YYYY-MM-DD] [-to YYYY-MM-DD] | [-date YYYY-MM-DD] [-mode before|after|nearest|interpolated]] [-format table|csv|json] [-o file]  (price database)",
+	run:   runPrices,
+}
+
+// pricePoint type: the JSON form of a model.Price
+type pricePoint struct {
+	Commodity string `json:"commodity,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	Date      string `json:"date"`
+	Value     string `json:"value"`
+	Source    string `json:"source,omitempty"`
+}
+
+// priceSeries type: the chart-ready price history of a commodity
+type priceSeries struct {
+	Commodity string       `json:"commodity"`
+	Currency  string       `json:"currency"`
+	From      string       `json:"from,omitempty"`
+	To        string       `json:"to,omitempty"`
+	Series    []pricePoint `json:"series"`
+}
+
+// pricePrecision is the maximum number of decimal digits of the prices
+const pricePrecision = 8
+
+// priceString returns the price value with at least 2 decimal digits,
+// exactly if it has up to pricePrecision digits, else rounded
+func priceString(v numeric.Numeric) string {
+	s := v.DecimalString(2)
+	if j := strings.IndexByte(s, '.'); j >= 0 && len(s)-j-1 > pricePrecision {
+		return v.FloatString(pricePrecision)
+	}
+	return s
+}
+
+func newPricePoint(p *model.Price, pair bool) pricePoint {
+	pp := pricePoint{
+		Date:   p.Time.Format("2006-01-02"),
+		Value:  priceString(p.Value),
+		Source: p.Source,
+	}
+	if pair {
+		pp.Commodity, pp.Currency = p.Commodity, p.Currency
+	}
+	return pp
+}
+
+// latestPrices returns the latest price of each commodity in each of its
+// currencies
+func latestPrices(db *model.PriceDB) []pricePoint {
+	list := []pricePoint{}
+	for _, commodity := range db.Commodities() {
+		for _, currency := range db.Currencies(commodity) {
+			if p := db.Latest(commodity, currency); p != nil {
+				list = append(list, newPricePoint(p, true))
+			}
+		}
+	}
+	return list
+}
+
+// priceHistory returns the prices of the commodity in the currency (empty
+// for the book currency) from the day from to the day to (empty for no limit)
+func priceHistory(book *model.Book, commodity, currency, from, to string) (*priceSeries, error) {
+	start, err := parseDate(from, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate(to, true)
+	if err != nil {
+		return nil, err
+	}
+	db := book.PriceDB()
+	if currency == "" {
+		currency = db.Base
+	}
+	res := &priceSeries{Commodity: commodity, Currency: currency, From: from, To: to, Series: []pricePoint{}}
+	for _, p := range db.History(commodity, currency, start, end) {
+		res.Series = append(res.Series, newPricePoint(p, false))
+	}
+	return res, nil
+}
+
+// priceAt returns the price of the commodity in the currency (empty for
+// the book currency) at the date (empty for the latest price) according
+// to the lookup mode. The time of the date is the end of the day for the
+// mode before, the beginning for after and midday for the others.
+func priceAt(book *model.Book, commodity, currency, date, mode string) (*pricePoint, error) {
+	t, err := parseDate(date, false)
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsZero() {
+		switch mode {
+		case model.PriceBefore:
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		case model.PriceAfter:
+		default:
+			t = t.Add(12 * time.Hour)
+		}
+	}
+	db := book.PriceDB()
+	if currency == "" {
+		currency = db.Base
+	}
+	p, err := db.At(commodity, currency, t, mode)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("Price not found: %s in %s", commodity, currency)
+	}
+	pp := newPricePoint(p, true)
+	return &pp, nil
+}
+
+// writePricesTable writes a row for each price
+func writePricesTable(w io.Writer, list []pricePoint) {
+	fmt.Fprintf(w, "%-10s %-10s %-10s %16s %s\n", "Date", "Commodity", "Currency", "Value", "Source")
+	for _, p := range list {
+		fmt.Fprintf(w, "%-10s %-10s %-10s %16s %s\n", p.Date, p.Commodity, p.Currency, p.Value, p.Source)
+	}
+}
+
+// writePricesCSV writes a row for each price
+func writePricesCSV(w io.Writer, list []pricePoint) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "commodity", "currency", "value", "source"})
+	for _, p := range list {
+		cw.Write([]string{p.Date, p.Commodity, p.Currency, p.Value, p.Source})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func runPrices(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("prices", flag.ContinueOnError)
+	commodity := fs.String("commodity", "", "commodity of the prices (default the latest price of each commodity)")
+	currency := fs.String("currency", "", "currency of the prices (default the book currency)")
+	from := fs.String("from", "", "first day of the history (default the first price)")
+	to := fs.String("to", "", "last day of the history (default the last price)")
+	date := fs.String("date", "", "day of the single price to look up")
+	mode := fs.String("mode", model.PriceBefore, "lookup mode of -date: before, after, nearest or interpolated")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var list []pricePoint
+	var res interface{}
+	switch {
+	case *commodity == "":
+		list = latestPrices(book.PriceDB())
+		res = list
+	case *date != "":
+		p, err := priceAt(book, *commodity, *currency, *date, *mode)
+		if err != nil {
+			return err
+		}
+		list, res = []pricePoint{*p}, p
+	default:
+		s, err := priceHistory(book, *commodity, *currency, *from, *to)
+		if err != nil {
+			return err
+		}
+		for _, p := range s.Series {
+			p.Commodity, p.Currency = s.Commodity, s.Currency
+			list = append(list, p)
+		}
+		res = s
+	}
+
+	w, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	switch *format {
+	case "table":
+		writePricesTable(w, list)
+		return nil
+	case "csv":
+		return writePricesCSV(w, list)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
+
+// httpPrices is the handler of the /prices[?commodity=id][&currency=id][&from=date][&to=date] endpoint:
+// the price history of the commodity, or the latest price of each commodity
+func httpPrices(srv *server, r *http.Request) (interface{}, error) {
+	commodity := r.FormValue("commodity")
+	if commodity == "" {
+		return latestPrices(srv.book.PriceDB()), nil
+	}
+	return priceHistory(srv.book, commodity, r.FormValue("currency"), r.FormValue("from"), r.FormValue("to"))
+}
+
+// httpPrice is the handler of the /price?commodity=id[&currency=id][&date=date][&mode=before|after|nearest|interpolated] endpoint
+func httpPrice(srv *server, r *http.Request) (interface{}, error) {
+	mode := r.FormValue("mode")
+	if mode == "" {
+		mode = model.PriceBefore
+	}
+	return priceAt(srv.book, r.FormValue("commodity"), r.FormValue("currency"), r.FormValue("date"), mode)
+}
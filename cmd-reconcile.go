@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/importer"
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+const reconcileUsage = `reconcile -account name [-format csv|ofx] [-balance amount] [-date YYYY-MM-DD] [-window days]
+         [-n] [-y] [-o file] [-force] [csv format flags as in import] statement-file`
+
+var cmdReconcile = &command{
+	name:  "reconcile",
+	usage: reconcileUsage,
+	run:   runReconcile,
+}
+
+// confirm asks a yes/no question on the standard input
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func printReconcileSplit(prefix string, at *model.AccountTransaction) {
+	fmt.Printf("%s %s %s %10s %s\n",
+		prefix,
+		at.Transaction.DatePosted.Format("2006-01-02"),
+		StringPad(at.Description(), 40, " "),
+		at.Split.Quantity.DecimalString(2),
+		at.Split.ReconciledState)
+}
+
+func printReconcileLine(prefix string, line *importer.Line) {
+	fmt.Printf("%s %s %s %10s\n",
+		prefix,
+		line.Date.Format("2006-01-02"),
+		StringPad(line.Description, 40, " "),
+		line.Amount.DecimalString(2))
+}
+
+func runReconcile(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	accountName := fs.String("account", "", "account of the statement")
+	format := fs.String("format", "csv", "statement format: csv or ofx")
+	balance := fs.String("balance", "", "statement ending balance (default from the statement)")
+	date := fs.String("date", "", "statement date (default from the statement)")
+	window := fs.Int("window", importer.DefaultDateWindow, "max days between statement line and split")
+	dryRun := fs.Bool("n", false, "don't write the book")
+	yes := fs.Bool("y", false, "don't ask for confirmation")
+	output := fs.String("o", "", "output file (default the GnuCash file itself)")
+	force := fs.Bool("force", false, "write the file even if locked by GnuCash")
+	csvFormat := csvFormatFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("Missing statement file")
+	}
+
+	rec := &importer.Reconciler{DateWindow: *window}
+	var err error
+	if rec.Account, err = findAccount(book, *accountName); err != nil {
+		return err
+	}
+	cf, err := csvFormat()
+	if err != nil {
+		return err
+	}
+	st, err := readStatement(fs.Arg(0), *format, cf)
+	if err != nil {
+		return err
+	}
+	if *balance != "" {
+		b, err := numeric.FromDecimal(*balance)
+		if err != nil {
+			return fmt.Errorf("Invalid balance: %s", *balance)
+		}
+		st.EndingBalance = &b
+	}
+	if *date != "" {
+		if st.EndingDate, err = parseDate(*date, false); err != nil {
+			return err
+		}
+	}
+
+	r, err := rec.Match(st)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Matched: %d\n", len(r.Matched))
+	for _, p := range r.Matched {
+		printReconcileSplit("  =", p.Split)
+	}
+	fmt.Printf("\nStatement lines without split: %d\n", len(r.UnmatchedLines))
+	for _, line := range r.UnmatchedLines {
+		printReconcileLine("  +", line)
+	}
+	fmt.Printf("\nSplits not in the statement: %d\n", len(r.UnmatchedSplits))
+	for _, at := range r.UnmatchedSplits {
+		printReconcileSplit("  -", at)
+	}
+
+	fmt.Printf("\nStatement date:    %s\n", r.Date.Format("2006-01-02"))
+	fmt.Printf("Starting balance:  %12s\n", r.StartingBalance.DecimalString(2))
+	fmt.Printf("Ending balance:    %12s\n", r.EndingBalance.DecimalString(2))
+	if st.EndingBalance == nil {
+		return errors.New("Missing statement ending balance: use -balance")
+	}
+	diff := r.Difference()
+	fmt.Printf("Statement balance: %12s\n", st.EndingBalance.DecimalString(2))
+	fmt.Printf("Difference:        %12s\n", diff.DecimalString(2))
+
+	if !r.Balanced() {
+		return errors.New("Statement not balanced: the book was not changed")
+	}
+	if *dryRun || len(r.Matched) == 0 {
+		return nil
+	}
+
+	path := *output
+	if path == "" {
+		path = *gnucashPath
+	}
+	if !*yes && !confirm(fmt.Sprintf("\nMark %d splits as reconciled and write %s?", len(r.Matched), path)) {
+		return nil
+	}
+	f, err := gncxml.ReadRawFile(*gnucashPath)
+	if err != nil {
+		return err
+	}
+	if err := r.Apply(f); err != nil {
+		return err
+	}
+	return f.WriteFile(path, *force)
+}
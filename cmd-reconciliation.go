@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdReconciliation = &command{
+	name:  "reconciliation",
+	usage: "reconciliation [-account name] [-date YYYY-MM-DD]  (all the accounts with splits if no account is given)",
+	run:   runReconciliation,
+}
+
+// reconciliationResult type: the JSON form of a model.Reconciliation
+type reconciliationResult struct {
+	Account           string         `json:"account"`
+	Date              string         `json:"date"`
+	LastReconcileDate string         `json:"last_reconcile_date,omitempty"`
+	ReconciledBalance string         `json:"reconciled_balance"`
+	ClearedBalance    string         `json:"cleared_balance"`
+	PresentBalance    string         `json:"present_balance"`
+	FutureBalance     string         `json:"future_balance"`
+	Cleared           []searchResult `json:"cleared,omitempty"`
+	New               []searchResult `json:"new,omitempty"`
+}
+
+func newReconciliationResult(r *model.Reconciliation, details bool) reconciliationResult {
+	res := reconciliationResult{
+		Account:           r.Account.FullName(),
+		Date:              r.Date.Format("2006-01-02"),
+		ReconciledBalance: r.ReconciledBalance.DecimalString(2),
+		ClearedBalance:    r.ClearedBalance.DecimalString(2),
+		PresentBalance:    r.PresentBalance.DecimalString(2),
+		FutureBalance:     r.FutureBalance.DecimalString(2),
+	}
+	if !r.LastReconcileDate.IsZero() {
+		res.LastReconcileDate = r.LastReconcileDate.Format("2006-01-02")
+	}
+	if details {
+		for _, at := range r.Cleared {
+			res.Cleared = append(res.Cleared, newSearchResult(at))
+		}
+		for _, at := range r.New {
+			res.New = append(res.New, newSearchResult(at))
+		}
+	}
+	return res
+}
+
+// reconciliations returns the reconciliation of the named account, or of
+// all the accounts with splits if name is empty
+func reconciliations(book *model.Book, name string, date string) ([]*model.Reconciliation, error) {
+	d, err := parseDate(date, true)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		acc, err := findAccount(book, name)
+		if err != nil {
+			return nil, err
+		}
+		return []*model.Reconciliation{acc.Reconciliation(d)}, nil
+	}
+	list := []*model.Reconciliation{}
+	book.Accounts.Walk(func(a *model.Account, level int) {
+		if len(a.AccountTransactionList) > 0 {
+			list = append(list, a.Reconciliation(d))
+		}
+	})
+	return list, nil
+}
+
+func runReconciliation(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("reconciliation", flag.ContinueOnError)
+	accountName := fs.String("account", "", "account full name or name")
+	date := fs.String("date", "", "date of the present balance (default today)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	list, err := reconciliations(book, *accountName, *date)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %-10s %12s %12s %12s %12s %5s %5s\n",
+		StringPad("Account", 30, " "), "Last rec.", "Reconciled", "Cleared", "Present", "Future", "#clr", "#new")
+	for _, r := range list {
+		res := newReconciliationResult(r, false)
+		fmt.Printf("%s %-10s %12s %12s %12s %12s %5d %5d\n",
+			StringPad(res.Account, 30, " "),
+			res.LastReconcileDate,
+			res.ReconciledBalance,
+			res.ClearedBalance,
+			res.PresentBalance,
+			res.FutureBalance,
+			len(r.Cleared),
+			len(r.New))
+	}
+	if *accountName == "" {
+		return nil
+	}
+
+	r := list[0]
+	for _, group := range []struct {
+		title string
+		list  []*model.AccountTransaction
+	}{
+		{"Cleared, not reconciled", r.Cleared},
+		{"New", r.New},
+	} {
+		fmt.Printf("\n%s: %d\n", group.title, len(group.list))
+		for _, at := range group.list {
+			fmt.Printf("%s %s %10s %s\n",
+				at.Transaction.DatePosted.Format("2006-01-02"),
+				StringPad(at.Description(), 40, " "),
+				at.Split.Quantity.DecimalString(2),
+				at.Split.ReconciledState)
+		}
+	}
+	return nil
+}
+
+// httpReconciliation is the handler of the /reconciliation[?account=name][&date=YYYY-MM-DD] endpoint.
+// The lists of the cleared and new splits are returned only for a single account.
+func httpReconciliation(srv *server, r *http.Request) (interface{}, error) {
+	name := r.FormValue("account")
+	list, err := reconciliations(srv.book, name, r.FormValue("date"))
+	if err != nil {
+		return nil, err
+	}
+	res := []reconciliationResult{}
+	for _, rec := range list {
+		res = append(res, newReconciliationResult(rec, name != ""))
+	}
+	return res, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdScheduled = &command{
+	name:  "scheduled",
+	usage: "scheduled [-date YYYY-MM-DD] [-days n] [-v]  (scheduled transactions, due and overdue)",
+	run:   runScheduled,
+}
+
+// nextHorizonYears is how far the next occurrence of a scheduled
+// transaction is searched
+const nextHorizonYears = 10
+
+// today returns the local midnight of the current day
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// scheduleString returns the description of the schedule
+func scheduleString(sx *model.ScheduledTransaction) string {
+	list := make([]string, len(sx.Schedule))
+	for j := range sx.Schedule {
+		list[j] = sx.Schedule[j].String()
+	}
+	return strings.Join(list, ", ")
+}
+
+// formatDate formats the date, or returns "-" if zero
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+func runScheduled(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("scheduled", flag.ContinueOnError)
+	date := fs.String("date", "", "reference date (default today)")
+	days := fs.Int("days", 0, "days in advance to consider an occurrence due, besides the remind days of the scheduled transaction")
+	verbose := fs.Bool("v", false, "print the template splits")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	day, err := parseDate(*date, false)
+	if err != nil {
+		return err
+	}
+	if day.IsZero() {
+		day = today()
+	}
+
+	fmt.Printf("%s %s %-10s %-10s %s\n", StringPad("Name", 25, " "), StringPad("Schedule", 35, " "), "Last", "Next", "Status")
+	for _, sx := range book.ScheduledTransactions {
+		overdue := sx.NextOccurrences(time.Time{}, day.Add(-time.Nanosecond))
+		var next time.Time
+		if list := sx.NextOccurrences(day, day.AddDate(nextHorizonYears, 0, 0)); len(list) > 0 {
+			next = list[0]
+		}
+
+		var status string
+		switch {
+		case !sx.Enabled:
+			status = "disabled"
+		case len(overdue) > 0:
+			status = fmt.Sprintf("overdue (%d, since %s)", len(overdue), formatDate(overdue[0]))
+		case next.IsZero():
+			status = "ended"
+		case !next.After(day.AddDate(0, 0, *days+sx.AdvanceRemindDays)):
+			status = "due"
+		}
+
+		fmt.Printf("%s %s %-10s %-10s %s\n",
+			StringPad(sx.Name, 25, " "),
+			StringPad(scheduleString(sx), 35, " "),
+			formatDate(sx.Last),
+			formatDate(next),
+			status)
+
+		if !*verbose {
+			continue
+		}
+		for _, tt := range sx.Templates {
+			for _, ts := range tt.Splits {
+				account := "<account not found>"
+				if ts.Account != nil {
+					account = ts.Account.FullName()
+				}
+				amount := "?"
+				if v, err := ts.Value(); err == nil {
+					amount = v.DecimalString(2)
+				}
+				fmt.Printf("    %s %12s  %s\n", StringPad(account, 40, " "), amount, ts.Split.Memo)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/query"
+)
+
+var cmdSearch = &command{
+	name:  "search",
+	usage: `search [-limit n] "query"  (e.g. account:Expenses:Auto* and date>=2015-01-01 and amount>50 and desc~"benzina")`,
+	run:   runSearch,
+}
+
+// searchResult type: a split matching the query
+type searchResult struct {
+	Date          string `json:"date"`
+	TransactionID string `json:"transaction_id"`
+	SplitID       string `json:"split_id"`
+	Description   string `json:"description"`
+	Memo          string `json:"memo,omitempty"`
+	Account       string `json:"account"`
+	Value         string `json:"value"`
+	Balance       string `json:"balance"`
+	Reconciled    string `json:"reconciled"`
+}
+
+func newSearchResult(at *model.AccountTransaction) searchResult {
+	return searchResult{
+		Date:          at.Transaction.DatePosted.Format("2006-01-02"),
+		TransactionID: at.Transaction.ID,
+		SplitID:       at.Split.ID,
+		Description:   at.Transaction.Description,
+		Memo:          at.Split.Memo,
+		Account:       at.Split.Account.FullName(),
+		Value:         at.Split.Value.DecimalString(2),
+		Balance:       at.Balance.DecimalString(2),
+		Reconciled:    at.Split.ReconciledState,
+	}
+}
+
+func runSearch(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "max number of results (0 no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	q, err := query.Compile(strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+	list := q.Search(book)
+	for j, at := range list {
+		if *limit > 0 && j >= *limit {
+			break
+		}
+		r := newSearchResult(at)
+		fmt.Printf("%s %s %s %10s %s\n",
+			r.Date,
+			StringPad(r.Description, 30, " "),
+			StringPad(r.Account, 30, " "),
+			r.Value,
+			r.Memo)
+	}
+	fmt.Printf("\nsplits found: %d\n", len(list))
+	return nil
+}
+
+// httpSearch is the handler of the /search?q=query[&limit=n] endpoint
+func httpSearch(srv *server, r *http.Request) (interface{}, error) {
+	q, err := query.Compile(r.FormValue("q"))
+	if err != nil {
+		return nil, err
+	}
+	limit, err := formInt(r, "limit", 0)
+	if err != nil {
+		return nil, err
+	}
+	res := []searchResult{}
+	for j, at := range q.Search(srv.book) {
+		if limit > 0 && j >= limit {
+			break
+		}
+		res = append(res, newSearchResult(at))
+	}
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mmbros/gnucash-viewer/index"
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdServe = &command{
+	name:  "serve",
+	usage: "serve [-addr host:port]",
+	run:   runServe,
+}
+
+// server type: the state shared by the HTTP handlers
+type server struct {
+	book  *model.Book
+	index *index.Index
+}
+
+// endpoints maps the HTTP paths to their handlers.
+// Each handler returns the value to be sent as JSON.
+var endpoints = map[string]func(srv *server, r *http.Request) (interface{}, error){
+	"/budget":         httpBudget,
+	"/currency-gains": httpCurrencyGains,
+	"/search":         httpSearch,
+	"/find":           httpFind,
+	"/forecast":       httpForecast,
+	"/gains":          httpGains,
+	"/portfolio":      httpPortfolio,
+	"/price":          httpPrice,
+	"/prices":         httpPrices,
+	"/reconciliation": httpReconciliation,
+	"/tax":            httpTax,
+}
+
+// formInt returns the integer value of the form field, or def if missing.
+func formInt(r *http.Request, name string, def int) (int, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %s: %s", name, v)
+	}
+	return n, nil
+}
+
+// jsonHandler returns an http.Handler that sends the result of fn as JSON.
+// Errors are sent as {"error": "message"} with status 400.
+func jsonHandler(srv *server, fn func(srv *server, r *http.Request) (interface{}, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		res, err := fn(srv, r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			res = map[string]string{"error": err.Error()}
+		}
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			log.Printf("%s: %s", r.URL, err)
+		}
+	})
+}
+
+func runServe(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	srv := &server{book: book, index: index.New(book)}
+	mux := http.NewServeMux()
+	for path, fn := range endpoints {
+		mux.Handle(path, jsonHandler(srv, fn))
+	}
+	log.Printf("listening on http://%s", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/classify"
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdSuggest = &command{
+	name:  "suggest",
+	usage: `suggest [-account name] [-n count] "description"`,
+	run:   runSuggest,
+}
+
+func runSuggest(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	accountName := fs.String("account", "", "import account whose bayes map is used (default all)")
+	n := fs.Int("n", 5, "max number of candidates")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Missing description")
+	}
+
+	var acc *model.Account
+	if *accountName != "" {
+		var err error
+		if acc, err = findAccount(book, *accountName); err != nil {
+			return err
+		}
+	}
+
+	c := classify.New(book, acc)
+	candidates := c.Classify(strings.Join(fs.Args(), " "))
+	if len(candidates) == 0 {
+		fmt.Println("No candidate account found")
+		return nil
+	}
+	for j, cand := range candidates {
+		if j >= *n {
+			break
+		}
+		fmt.Printf("%6.2f%% %s\n", cand.Probability*100, cand.Account.FullName())
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/export"
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdTax = &command{
+	name:  "tax",
+	usage: "tax [-year YYYY | -from YYYY-MM-DD -to YYYY-MM-DD] [-detail] [-format table|csv|txf|json] [-italian] [-o file]  (totals of the tax-related accounts)",
+	run:   runTax,
+}
+
+// taxItem type: the JSON form of a split of a model.TaxGroup
+type taxItem struct {
+	Date        string `json:"date"`
+	Account     string `json:"account"`
+	Description string `json:"description"`
+	Memo        string `json:"memo,omitempty"`
+	Amount      string `json:"amount"`
+}
+
+// taxGroup type: the JSON form of a model.TaxGroup
+type taxGroup struct {
+	Scheme   string    `json:"scheme,omitempty"`
+	Code     string    `json:"code,omitempty"`
+	Name     string    `json:"name"`
+	Accounts []string  `json:"accounts"`
+	Total    string    `json:"total"`
+	Items    []taxItem `json:"items,omitempty"`
+}
+
+// taxResult type: the JSON form of a model.TaxReport
+type taxResult struct {
+	From   string     `json:"from"`
+	To     string     `json:"to"`
+	Groups []taxGroup `json:"groups"`
+}
+
+// taxPeriod returns the period of the tax report: the year if not zero,
+// else from the day from (empty for the beginning) to the day to (empty
+// for today)
+func taxPeriod(year int, from, to string) (time.Time, time.Time, error) {
+	if year != 0 {
+		if from != "" || to != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid period: both year and from/to given")
+		}
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(1, 0, -1), nil
+	}
+	start, err := parseDate(from, false)
+	if err != nil {
+		return start, start, err
+	}
+	end, err := parseDate(to, false)
+	if err != nil {
+		return start, end, err
+	}
+	if end.IsZero() {
+		end = today()
+	}
+	if !start.IsZero() && end.Before(start) {
+		return start, end, fmt.Errorf("Invalid period: %s > %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	return start, end, nil
+}
+
+func newTaxResult(r *model.TaxReport, detail bool) *taxResult {
+	res := &taxResult{
+		To:     r.To.Format("2006-01-02"),
+		Groups: []taxGroup{},
+	}
+	if !r.From.IsZero() {
+		res.From = r.From.Format("2006-01-02")
+	}
+	for _, g := range r.Groups {
+		tg := taxGroup{
+			Scheme: g.Scheme,
+			Code:   g.Code,
+			Name:   g.Name(),
+			Total:  g.Total.FloatString(2),
+		}
+		for _, a := range g.Accounts {
+			tg.Accounts = append(tg.Accounts, a.FullName())
+		}
+		if detail {
+			for _, at := range g.Items {
+				v := at.NaturalValue()
+				tg.Items = append(tg.Items, taxItem{
+					Date:        at.Transaction.DatePosted.Format("2006-01-02"),
+					Account:     at.Split.Account.FullName(),
+					Description: at.Transaction.Description,
+					Memo:        at.Split.Memo,
+					Amount:      v.FloatString(2),
+				})
+			}
+		}
+		res.Groups = append(res.Groups, tg)
+	}
+	return res
+}
+
+// writeTaxTable writes the total of each group, with its splits if detailed
+func writeTaxTable(w io.Writer, res *taxResult) {
+	from := res.From
+	if from == "" {
+		from = "the beginning"
+	}
+	fmt.Fprintf(w, "Tax report from %s to %s\n\n", from, res.To)
+	for _, g := range res.Groups {
+		fmt.Fprintf(w, "%s %12s\n", StringPad(g.Name, 50, " "), g.Total)
+		if g.Code != "" || len(g.Items) > 0 {
+			for _, name := range g.Accounts {
+				fmt.Fprintf(w, "  %s\n", name)
+			}
+		}
+		for _, item := range g.Items {
+			fmt.Fprintf(w, "    %s %s %12s\n", item.Date, StringPad(item.Description, 35, " "), item.Amount)
+		}
+	}
+	if len(res.Groups) == 0 {
+		fmt.Fprintln(w, "No tax-related splits")
+	}
+}
+
+func runTax(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("tax", flag.ContinueOnError)
+	year := fs.Int("year", 0, "tax year (default -from and -to)")
+	from := fs.String("from", "", "first day (default the beginning)")
+	to := fs.String("to", "", "last day (default today)")
+	detail := fs.Bool("detail", false, "list the splits of each group (table and json)")
+	format := fs.String("format", "table", "output format: table, csv, txf or json")
+	italian := fs.Bool("italian", false, "use the Italian spreadsheet defaults (csv)")
+	output := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	start, end, err := taxPeriod(*year, *from, *to)
+	if err != nil {
+		return err
+	}
+	r := book.TaxReport(start, end)
+
+	w, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	switch *format {
+	case "table":
+		writeTaxTable(w, newTaxResult(r, *detail))
+		return nil
+	case "csv":
+		opts := export.CSVOptions{}
+		if *italian {
+			opts = export.ItalianCSVOptions
+		}
+		return export.WriteTaxCSV(w, r, &opts)
+	case "txf":
+		return export.WriteTXF(w, r, time.Now())
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newTaxResult(r, *detail))
+	}
+	return fmt.Errorf("Invalid -format value: %s", *format)
+}
+
+// httpTax is the handler of the /tax[?year=n][&from=date][&to=date][&detail=1] endpoint
+func httpTax(srv *server, r *http.Request) (interface{}, error) {
+	year, err := formInt(r, "year", 0)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := taxPeriod(year, r.FormValue("from"), r.FormValue("to"))
+	if err != nil {
+		return nil, err
+	}
+	return newTaxResult(srv.book.TaxReport(start, end), r.FormValue("detail") != ""), nil
+}
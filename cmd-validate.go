@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+var cmdValidate = &command{
+	name:  "validate",
+	usage: "validate [-format text|json] [-severity info|warning|error]  (exit status 1 if errors are found)",
+	run:   runValidate,
+}
+
+func runValidate(book *model.Book, args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	minSeverity := fs.String("severity", "info", "minimum severity of the reported problems")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	min, err := model.ParseSeverity(*minSeverity)
+	if err != nil {
+		return err
+	}
+
+	problems := []*model.Problem{}
+	var errorCount int
+	for _, p := range book.Validate(time.Time{}) {
+		if p.Severity == model.SeverityError {
+			errorCount++
+		}
+		if p.Severity >= min {
+			problems = append(problems, p)
+		}
+	}
+
+	switch *format {
+	case "text":
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		fmt.Printf("\nproblems found: %d, errors: %d\n", len(problems), errorCount)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(problems); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Invalid format: %s", *format)
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("Book not valid: %d errors", errorCount)
+	}
+	return nil
+}
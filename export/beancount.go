@@ -0,0 +1,228 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+	"github.com/mmbros/gnucash-viewer/text"
+)
+
+// beancountRoots maps the GnuCash account types to the Beancount root accounts
+var beancountRoots = map[string]string{
+	"ASSET":      "Assets",
+	"RECEIVABLE": "Assets",
+	"STOCK":      "Assets",
+	"MUTUAL":     "Assets",
+	"BANK":       "Assets",
+	"CASH":       "Assets",
+	"LIABILITY":  "Liabilities",
+	"CREDIT":     "Liabilities",
+	"PAYABLE":    "Liabilities",
+	"EQUITY":     "Equity",
+	"TRADING":    "Equity",
+	"INCOME":     "Income",
+	"EXPENSE":    "Expenses",
+}
+
+// beancountDate is the layout of the Beancount dates
+const beancountDate = "2006-01-02"
+
+// beancountComponent returns a valid Beancount account name component:
+// it starts with a capital letter or digit and contains only ASCII letters,
+// digits and dashes (e.g. "conto corrente" -> "Conto-corrente").
+func beancountComponent(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range text.RemoveAccents(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	s := b.String()
+	if s == "" {
+		return "X"
+	}
+	if r := rune(s[0]); unicode.IsLower(r) {
+		s = string(unicode.ToUpper(r)) + s[1:]
+	}
+	return s
+}
+
+// beancountCommodity returns a valid Beancount commodity name.
+func beancountCommodity(id string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(text.RemoveAccents(id)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("'._-", r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	s := b.String()
+	if s == "" || !unicode.IsLetter(rune(s[0])) {
+		s = "X" + s
+	}
+	if len(s) > 24 {
+		s = s[:24]
+	}
+	// the last character must be a letter or a digit
+	return strings.TrimRight(s, "'._-")
+}
+
+// beancountString returns s as a double quoted Beancount string.
+func beancountString(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + strings.Join(strings.Fields(s), " ") + `"`
+}
+
+// beancountAccountNames returns the Beancount name of each account.
+// The top level names are prefixed by the root given by the account type.
+func beancountAccountNames(accounts *model.Accounts) map[*model.Account]string {
+	names := map[*model.Account]string{}
+	used := map[string]bool{}
+
+	accounts.Walk(func(a *model.Account, level int) {
+		if a.Parent == nil {
+			// skip root account
+			return
+		}
+		var name string
+		if parent, ok := names[a.Parent]; ok {
+			name = parent + ":" + beancountComponent(a.Name)
+		} else {
+			root, ok := beancountRoots[a.Type.Code()]
+			if !ok {
+				root = "Equity"
+			}
+			name = root + ":" + beancountComponent(a.Name)
+		}
+		// names must be unique
+		unique := name
+		for j := 2; used[unique]; j++ {
+			unique = fmt.Sprintf("%s-%d", name, j)
+		}
+		used[unique] = true
+		names[a] = unique
+	})
+	return names
+}
+
+// WriteBeancount writes the book as a Beancount ledger.
+func WriteBeancount(w io.Writer, book *model.Book) error {
+	p := newPrinter(w)
+	names := beancountAccountNames(book.Accounts)
+
+	// the first date of the book, used for the commodity directives
+	var first time.Time
+	if len(book.Transactions) > 0 {
+		first = book.Transactions[0].DatePosted
+	}
+	if len(book.Prices) > 0 && (first.IsZero() || book.Prices[0].Time.Before(first)) {
+		first = book.Prices[0].Time
+	}
+
+	// commodity directives
+	commodities := map[string]bool{}
+	for _, t := range book.Transactions {
+		commodities[t.Currency] = true
+	}
+	for a := range names {
+		commodities[a.Currency] = true
+	}
+	for _, price := range book.Prices {
+		commodities[price.Commodity] = true
+		commodities[price.Currency] = true
+	}
+	for _, id := range sortedKeys(commodities) {
+		c := book.Commodities.Get(id)
+		p.printf("%s commodity %s\n", first.Format(beancountDate), beancountCommodity(id))
+		if c.Name != "" {
+			p.printf("  name: %s\n", beancountString(c.Name))
+		}
+	}
+	p.printf("\n")
+
+	// open directives
+	book.Accounts.Walk(func(a *model.Account, level int) {
+		name, ok := names[a]
+		if !ok || len(a.AccountTransactionList) == 0 {
+			// skip root account and accounts without transactions
+			return
+		}
+		p.printf("%s open %s\n", a.AccountTransactionList[0].Transaction.DatePosted.Format(beancountDate), name)
+	})
+	p.printf("\n")
+
+	// price directives
+	for _, price := range book.Prices {
+		p.printf("%s price %s %s\n",
+			price.Time.Format(beancountDate),
+			beancountCommodity(price.Commodity),
+			beancountAmount(price.Value, book.Commodities.Get(price.Currency)))
+	}
+	if len(book.Prices) > 0 {
+		p.printf("\n")
+	}
+
+	// transactions
+	for _, t := range book.Transactions {
+		currency := book.Commodities.Get(t.Currency)
+
+		p.printf("%s * %s\n", t.DatePosted.Format(beancountDate), beancountString(t.Description))
+		p.printf("  guid: %s\n", beancountString(t.ID))
+		for _, s := range t.Splits {
+			p.printf("  %s%s  ", ledgerStatus[s.ReconciledState], names[s.Account])
+			if s.Account.Currency == t.Currency || s.Quantity.Sign() == 0 {
+				p.printf("%s\n", beancountAmount(s.Value, currency))
+			} else {
+				cost := s.Value
+				if cost.Sign() < 0 {
+					cost.NegEqual()
+				}
+				p.printf("%s @@ %s\n",
+					beancountAmount(s.Quantity, book.Commodities.Get(s.Account.Currency)),
+					beancountAmount(cost, currency))
+			}
+			if s.Memo != "" {
+				p.printf("    memo: %s\n", beancountString(s.Memo))
+			}
+		}
+		p.printf("\n")
+	}
+
+	// close directives of the hidden accounts with zero balance
+	book.Accounts.Walk(func(a *model.Account, level int) {
+		name, ok := names[a]
+		if !ok || !a.Hidden() || len(a.AccountTransactionList) == 0 {
+			return
+		}
+		var qty numeric.Numeric
+		for _, at := range a.AccountTransactionList {
+			qty.AddEqual(&at.Split.Quantity)
+		}
+		if qty.Sign() != 0 {
+			return
+		}
+		last := a.AccountTransactionList[len(a.AccountTransactionList)-1]
+		p.printf("%s close %s\n", last.Transaction.DatePosted.AddDate(0, 0, 1).Format(beancountDate), name)
+	})
+
+	return p.flush()
+}
+
+// beancountAmount returns the amount in the given commodity.
+func beancountAmount(n numeric.Numeric, c *model.Commodity) string {
+	return n.DecimalString(c.Precision()) + " " + beancountCommodity(c.ID)
+}
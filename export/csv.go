@@ -0,0 +1,152 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// CSVOptions type
+type CSVOptions struct {
+	Delimiter        rune   // field delimiter (default ',')
+	DateFormat       string // time layout of dates (default "2006-01-02")
+	DecimalSeparator string // decimal separator of amounts (default ".")
+	Precision        *int   // digits after the decimal separator (default 2)
+}
+
+// ItalianCSVOptions are the options suited to Italian spreadsheets.
+var ItalianCSVOptions = CSVOptions{
+	Delimiter:        ';',
+	DateFormat:       "02/01/2006",
+	DecimalSeparator: ",",
+}
+
+// csvWriter wraps a csv.Writer with the formatting options
+type csvWriter struct {
+	*csv.Writer
+	opts CSVOptions
+	prec int
+}
+
+func newCSVWriter(w io.Writer, opts *CSVOptions) *csvWriter {
+	cw := &csvWriter{Writer: csv.NewWriter(w), prec: 2}
+	if opts != nil {
+		cw.opts = *opts
+	}
+	if cw.opts.Delimiter != 0 {
+		cw.Comma = cw.opts.Delimiter
+	}
+	if cw.opts.DateFormat == "" {
+		cw.opts.DateFormat = "2006-01-02"
+	}
+	if cw.opts.DecimalSeparator == "" {
+		cw.opts.DecimalSeparator = "."
+	}
+	if cw.opts.Precision != nil {
+		cw.prec = *cw.opts.Precision
+	}
+	return cw
+}
+
+func (cw *csvWriter) date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(cw.opts.DateFormat)
+}
+
+func (cw *csvWriter) amount(n numeric.Numeric) string {
+	s := n.FloatString(cw.prec)
+	if cw.opts.DecimalSeparator != "." {
+		s = strings.Replace(s, ".", cw.opts.DecimalSeparator, 1)
+	}
+	return s
+}
+
+func (cw *csvWriter) close() error {
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSplitsCSV writes one row for each split of the book.
+func WriteSplitsCSV(w io.Writer, book *model.Book, opts *CSVOptions) error {
+	cw := newCSVWriter(w, opts)
+
+	cw.Write([]string{"Date", "TransactionID", "Description", "Memo", "Account", "Value", "Quantity", "Reconciled"})
+	for _, t := range book.Transactions {
+		for _, s := range t.Splits {
+			cw.Write([]string{
+				cw.date(t.DatePosted),
+				t.ID,
+				t.Description,
+				s.Memo,
+				s.Account.FullName(),
+				cw.amount(s.Value),
+				cw.amount(s.Quantity),
+				s.ReconciledState,
+			})
+		}
+	}
+	return cw.close()
+}
+
+// WriteRegisterCSV writes the register of the account with the running balance.
+func WriteRegisterCSV(w io.Writer, account *model.Account, opts *CSVOptions) error {
+	cw := newCSVWriter(w, opts)
+
+	plusLabel, minusLabel := account.Type.Labels()
+	cw.Write([]string{"Date", "TransactionID", "Description", plusLabel, minusLabel, "Balance", "Reconciled"})
+	for _, at := range account.AccountTransactionList {
+		cw.Write([]string{
+			cw.date(at.Transaction.DatePosted),
+			at.Transaction.ID,
+			at.Description(),
+			cw.amount(at.PlusValue),
+			cw.amount(at.MinusValue),
+			cw.amount(at.Balance),
+			at.Split.ReconciledState,
+		})
+	}
+	return cw.close()
+}
+
+// totalBalance returns the balance of the account including the balances
+// of its sub-accounts in the same commodity
+func totalBalance(a *model.Account) numeric.Numeric {
+	balance := a.Balance()
+	for _, child := range a.Children {
+		if child.Currency == a.Currency {
+			b := totalBalance(child)
+			balance.AddEqual(&b)
+		}
+	}
+	return balance
+}
+
+// WriteAccountTreeCSV writes the account tree with the balance of each
+// account, including its sub-accounts in the same commodity.
+func WriteAccountTreeCSV(w io.Writer, accounts *model.Accounts, opts *CSVOptions) error {
+	cw := newCSVWriter(w, opts)
+
+	cw.Write([]string{"Account", "Name", "Level", "Type", "Currency", "Balance"})
+	accounts.Walk(func(a *model.Account, level int) {
+		if a.Parent == nil {
+			// skip root account
+			return
+		}
+		cw.Write([]string{
+			a.FullName(),
+			a.Name,
+			strconv.Itoa(level),
+			a.Type.Label(),
+			a.Currency,
+			cw.amount(totalBalance(a)),
+		})
+	})
+	return cw.close()
+}
@@ -0,0 +1,46 @@
+// Package export writes the content of a model.Book in formats
+// readable by other programs (CSV, ledger journals, ...).
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// sortedKeys returns the sorted keys of the map
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printer is a buffered writer that keeps the first error occurred
+type printer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newPrinter(w io.Writer) *printer {
+	return &printer{w: bufio.NewWriter(w)}
+}
+
+func (p *printer) printf(format string, a ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, a...)
+}
+
+func (p *printer) flush() error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// ledgerAccountTypes maps the GnuCash account types to the hledger ones
+var ledgerAccountTypes = map[string]string{
+	"ASSET":      "A",
+	"RECEIVABLE": "A",
+	"STOCK":      "A",
+	"MUTUAL":     "A",
+	"BANK":       "C",
+	"CASH":       "C",
+	"LIABILITY":  "L",
+	"CREDIT":     "L",
+	"PAYABLE":    "L",
+	"EQUITY":     "E",
+	"INCOME":     "R",
+	"EXPENSE":    "X",
+}
+
+// ledgerStatus maps the split reconciled state to the ledger status mark
+var ledgerStatus = map[string]string{
+	"y": "* ", // reconciled
+	"f": "* ", // frozen
+	"c": "! ", // cleared
+}
+
+// ledgerText removes newlines and repeated spaces, that have a special
+// meaning in ledger journals.
+func ledgerText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ledgerCommodity returns the commodity symbol, quoted if it contains
+// anything but letters.
+func ledgerCommodity(id string) string {
+	for _, r := range id {
+		if !unicode.IsLetter(r) {
+			return `"` + id + `"`
+		}
+	}
+	return id
+}
+
+// ledgerAmount returns the amount in the given commodity.
+func ledgerAmount(n numeric.Numeric, c *model.Commodity) string {
+	return n.DecimalString(c.Precision()) + " " + ledgerCommodity(c.ID)
+}
+
+// ledgerAccount returns the account name used in the journal.
+func ledgerAccount(a *model.Account) string {
+	return ledgerText(a.FullName())
+}
+
+// WriteLedger writes the book as a ledger/hledger plain-text journal.
+func WriteLedger(w io.Writer, book *model.Book) error {
+	p := newPrinter(w)
+
+	// commodity declarations
+	commodities := map[string]bool{}
+	for _, t := range book.Transactions {
+		commodities[t.Currency] = true
+	}
+	book.Accounts.Walk(func(a *model.Account, level int) {
+		if a.Parent != nil {
+			commodities[a.Currency] = true
+		}
+	})
+	for _, price := range book.Prices {
+		commodities[price.Commodity] = true
+		commodities[price.Currency] = true
+	}
+	for _, id := range sortedKeys(commodities) {
+		c := book.Commodities.Get(id)
+		p.printf("commodity %s\n", ledgerAmount(numeric.New(1000, 1), c))
+		if c.Name != "" {
+			p.printf("    ; name: %s\n", ledgerText(c.Name))
+		}
+	}
+	p.printf("\n")
+
+	// account declarations
+	book.Accounts.Walk(func(a *model.Account, level int) {
+		if a.Parent == nil {
+			// skip root account
+			return
+		}
+		p.printf("account %s", ledgerAccount(a))
+		if typ, ok := ledgerAccountTypes[a.Type.Code()]; ok {
+			p.printf("  ; type:%s", typ)
+		}
+		p.printf("\n")
+	})
+	p.printf("\n")
+
+	// price directives
+	for _, price := range book.Prices {
+		p.printf("P %s %s %s\n",
+			price.Time.Format("2006-01-02"),
+			ledgerCommodity(price.Commodity),
+			ledgerAmount(price.Value, book.Commodities.Get(price.Currency)))
+	}
+	if len(book.Prices) > 0 {
+		p.printf("\n")
+	}
+
+	// transactions
+	for _, t := range book.Transactions {
+		currency := book.Commodities.Get(t.Currency)
+
+		p.printf("%s %s\n", t.DatePosted.Format("2006-01-02"), ledgerText(t.Description))
+		p.printf("    ; guid:%s\n", t.ID)
+		for _, s := range t.Splits {
+			p.printf("    %s%s  ", ledgerStatus[s.ReconciledState], ledgerAccount(s.Account))
+			if s.Account.Currency == t.Currency || s.Quantity.Sign() == 0 {
+				p.printf("%s", ledgerAmount(s.Value, currency))
+			} else {
+				cost := s.Value
+				if cost.Sign() < 0 {
+					cost.NegEqual()
+				}
+				p.printf("%s @@ %s",
+					ledgerAmount(s.Quantity, book.Commodities.Get(s.Account.Currency)),
+					ledgerAmount(cost, currency))
+			}
+			if memo := ledgerText(s.Memo); memo != "" {
+				p.printf("  ; %s", memo)
+			}
+			p.printf("\n")
+		}
+		p.printf("\n")
+	}
+
+	return p.flush()
+}
@@ -0,0 +1,202 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// ofxHeader is the header of the OFX 2.x files
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// ofxDateTime is the layout of the OFX dates
+const ofxDateTime = "20060102150405"
+
+// ofxAccountTypes maps the GnuCash account types to the OFX bank account types
+var ofxAccountTypes = map[string]string{
+	"BANK":      "CHECKING",
+	"CASH":      "CHECKING",
+	"ASSET":     "SAVINGS",
+	"LIABILITY": "CREDITLINE",
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+var ofxStatusOK = ofxStatus{Code: 0, Severity: "INFO"}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Status   ofxStatus `xml:"SONRS>STATUS"`
+		DTServer string    `xml:"SONRS>DTSERVER"`
+		Language string    `xml:"SONRS>LANGUAGE"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Bank       *ofxBankMessage       `xml:"BANKMSGSRSV1,omitempty"`
+	CreditCard *ofxCreditCardMessage `xml:"CREDITCARDMSGSRSV1,omitempty"`
+}
+
+type ofxBankMessage struct {
+	TrnUID    string    `xml:"STMTTRNRS>TRNUID"`
+	Status    ofxStatus `xml:"STMTTRNRS>STATUS"`
+	Statement struct {
+		Currency    string          `xml:"CURDEF"`
+		AccountFrom ofxBankAccount  `xml:"BANKACCTFROM"`
+		TranList    ofxTransactions `xml:"BANKTRANLIST"`
+		LedgerBal   ofxBalance      `xml:"LEDGERBAL"`
+	} `xml:"STMTTRNRS>STMTRS"`
+}
+
+type ofxCreditCardMessage struct {
+	TrnUID    string    `xml:"CCSTMTTRNRS>TRNUID"`
+	Status    ofxStatus `xml:"CCSTMTTRNRS>STATUS"`
+	Statement struct {
+		Currency  string          `xml:"CURDEF"`
+		AccountID string          `xml:"CCACCTFROM>ACCTID"`
+		TranList  ofxTransactions `xml:"BANKTRANLIST"`
+		LedgerBal ofxBalance      `xml:"LEDGERBAL"`
+	} `xml:"CCSTMTTRNRS>CCSTMTRS"`
+}
+
+type ofxBankAccount struct {
+	BankID      string `xml:"BANKID"`
+	AccountID   string `xml:"ACCTID"`
+	AccountType string `xml:"ACCTTYPE"`
+}
+
+type ofxTransactions struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	Type     string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	Amount   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// ofxName returns s truncated to the 32 characters allowed in the NAME field
+func ofxName(s string) string {
+	r := []rune(qifText(s))
+	if len(r) > 32 {
+		r = r[:32]
+	}
+	return string(r)
+}
+
+// WriteOFX writes the account transactions as an OFX 2.x statement.
+// CREDIT accounts are written as credit card statements,
+// the other accounts as bank statements.
+func WriteOFX(w io.Writer, account *model.Account, opts *StatementOptions) error {
+	var o StatementOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.BankID == "" {
+		o.BankID = "0"
+	}
+	if o.AccountID == "" {
+		o.AccountID = account.ID
+	}
+	now := time.Now()
+
+	// transactions
+	list := statementTransactions(account, &o)
+	tl := ofxTransactions{Transactions: []ofxTransaction{}}
+	for _, at := range list {
+		typ := "CREDIT"
+		if at.Split.Quantity.Sign() < 0 {
+			typ = "DEBIT"
+		}
+		tl.Transactions = append(tl.Transactions, ofxTransaction{
+			Type:     typ,
+			DTPosted: at.Transaction.DatePosted.Format(ofxDateTime),
+			Amount:   at.Split.Quantity.DecimalString(2),
+			FITID:    at.Split.ID,
+			Name:     ofxName(at.Transaction.Description),
+			Memo:     qifText(at.Split.Memo),
+		})
+	}
+	start, end := o.From, o.To
+	if end.IsZero() {
+		end = now
+	}
+	if start.IsZero() {
+		// the first transaction of the statement, else of the account
+		switch {
+		case len(list) > 0:
+			start = list[0].Transaction.DatePosted
+		case len(account.AccountTransactionList) > 0 && !account.AccountTransactionList[0].Transaction.DatePosted.After(end):
+			start = account.AccountTransactionList[0].Transaction.DatePosted
+		default:
+			start = end
+		}
+	}
+	tl.DTStart = start.Format(ofxDateTime)
+	tl.DTEnd = end.Format(ofxDateTime)
+
+	// closing ledger balance
+	var balance numeric.Numeric
+	for _, at := range account.AccountTransactionList {
+		if at.Transaction.DatePosted.After(end) {
+			break
+		}
+		balance = at.Balance
+	}
+	bal := ofxBalance{
+		Amount: balance.DecimalString(2),
+		DTAsOf: end.Format(ofxDateTime),
+	}
+
+	doc := ofxDocument{}
+	doc.SignOn.Status = ofxStatusOK
+	doc.SignOn.DTServer = now.Format(ofxDateTime)
+	doc.SignOn.Language = "ENG"
+
+	if account.Type.Code() == "CREDIT" {
+		msg := &ofxCreditCardMessage{TrnUID: "1", Status: ofxStatusOK}
+		msg.Statement.Currency = account.Currency
+		msg.Statement.AccountID = o.AccountID
+		msg.Statement.TranList = tl
+		msg.Statement.LedgerBal = bal
+		doc.CreditCard = msg
+	} else {
+		acctType, ok := ofxAccountTypes[account.Type.Code()]
+		if !ok {
+			acctType = "CHECKING"
+		}
+		msg := &ofxBankMessage{TrnUID: "1", Status: ofxStatusOK}
+		msg.Statement.Currency = account.Currency
+		msg.Statement.AccountFrom = ofxBankAccount{BankID: o.BankID, AccountID: o.AccountID, AccountType: acctType}
+		msg.Statement.TranList = tl
+		msg.Statement.LedgerBal = bal
+		doc.Bank = msg
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
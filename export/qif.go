@@ -0,0 +1,112 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// StatementOptions type: options of the QIF and OFX account statements
+type StatementOptions struct {
+	From time.Time // first date (if not zero)
+	To   time.Time // last date (if not zero)
+
+	// OFX only
+	BankID    string // bank identifier (default "0")
+	AccountID string // account number (default the account ID)
+}
+
+// qifTypes maps the GnuCash account types to the QIF ones
+var qifTypes = map[string]string{
+	"BANK":       "Bank",
+	"CREDIT":     "CCard",
+	"CASH":       "Cash",
+	"ASSET":      "Oth A",
+	"RECEIVABLE": "Oth A",
+	"LIABILITY":  "Oth L",
+	"PAYABLE":    "Oth L",
+}
+
+// qifCleared maps the split reconciled state to the QIF cleared status
+var qifCleared = map[string]string{
+	"y": "X",
+	"f": "X",
+	"c": "*",
+}
+
+// statementTransactions returns the account transactions between opts.From and opts.To.
+func statementTransactions(account *model.Account, opts *StatementOptions) []*model.AccountTransaction {
+	list := []*model.AccountTransaction{}
+	for _, at := range account.AccountTransactionList {
+		date := at.Transaction.DatePosted
+		if opts != nil && !opts.From.IsZero() && date.Before(opts.From) {
+			continue
+		}
+		if opts != nil && !opts.To.IsZero() && date.After(opts.To) {
+			continue
+		}
+		list = append(list, at)
+	}
+	return list
+}
+
+// qifCategory returns the QIF category of the account:
+// the account path, enclosed in brackets for transfers.
+func qifCategory(a *model.Account) string {
+	switch a.Type.Code() {
+	case "INCOME", "EXPENSE":
+		return qifText(a.FullName())
+	}
+	return "[" + qifText(a.FullName()) + "]"
+}
+
+// qifText removes the newlines from s
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteQIF writes the account transactions in QIF format.
+func WriteQIF(w io.Writer, account *model.Account, opts *StatementOptions) error {
+	p := newPrinter(w)
+
+	typ, ok := qifTypes[account.Type.Code()]
+	if !ok {
+		typ = "Bank"
+	}
+	p.printf("!Type:%s\n", typ)
+
+	for _, at := range statementTransactions(account, opts) {
+		p.printf("D%s\n", at.Transaction.DatePosted.Format("01/02/2006"))
+		p.printf("T%s\n", at.Split.Quantity.DecimalString(2))
+		if cleared, ok := qifCleared[at.Split.ReconciledState]; ok {
+			p.printf("C%s\n", cleared)
+		}
+		p.printf("P%s\n", qifText(at.Transaction.Description))
+		if at.Split.Memo != "" {
+			p.printf("M%s\n", qifText(at.Split.Memo))
+		}
+
+		counter := at.CounterSplits()
+		switch len(counter) {
+		case 0:
+		case 1:
+			p.printf("L%s\n", qifCategory(counter[0].Account))
+		default:
+			// split transaction: the amounts are seen from the account side
+			for _, s := range counter {
+				v := numeric.Neg(&s.Value)
+				p.printf("S%s\n", qifCategory(s.Account))
+				if s.Memo != "" {
+					p.printf("E%s\n", qifText(s.Memo))
+				}
+				p.printf("$%s\n", v.DecimalString(2))
+			}
+		}
+		p.printf("^\n")
+	}
+
+	return p.flush()
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+// WriteTaxCSV writes one row for each split of the tax report,
+// with the code (or account) of its group.
+func WriteTaxCSV(w io.Writer, r *model.TaxReport, opts *CSVOptions) error {
+	cw := newCSVWriter(w, opts)
+
+	cw.Write([]string{"Scheme", "Code", "Account", "Date", "TransactionID", "Description", "Memo", "Amount"})
+	for _, g := range r.Groups {
+		for _, at := range g.Items {
+			cw.Write([]string{
+				g.Scheme,
+				g.Code,
+				at.Split.Account.FullName(),
+				cw.date(at.Transaction.DatePosted),
+				at.Transaction.ID,
+				at.Transaction.Description,
+				at.Split.Memo,
+				cw.amount(at.NaturalValue()),
+			})
+		}
+	}
+	return cw.close()
+}
+
+// txfLine replaces the line breaks, not allowed in the TXF fields
+func txfLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteTXF writes the groups of the tax report with a US tax code in the
+// Tax Exchange Format (V042), one detail record for each split. The
+// other groups are skipped, having no TXF code.
+func WriteTXF(w io.Writer, r *model.TaxReport, now time.Time) error {
+	p := newPrinter(w)
+
+	p.printf("V042\r\nAgnucash-viewer\r\nD%s\r\n^\r\n", now.Format("01/02/2006"))
+	for _, g := range r.Groups {
+		if g.Scheme != "US" {
+			continue
+		}
+		code := strings.TrimPrefix(g.Code, "N")
+		for _, at := range g.Items {
+			p.printf("TD\r\nN%s\r\nC1\r\nL1\r\n", code)
+			p.printf("D%s\r\n", at.Transaction.DatePosted.Format("01/02/2006"))
+			v := at.NaturalValue()
+			p.printf("$%s\r\n", v.FloatString(2))
+			if s := txfLine(at.Transaction.Description); s != "" {
+				p.printf("P%s\r\n", s)
+			}
+			if s := txfLine(at.Split.Memo); s != "" {
+				p.printf("X%s\r\n", s)
+			}
+			p.printf("^\r\n")
+		}
+	}
+	return p.flush()
+}
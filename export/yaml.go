@@ -0,0 +1,152 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// WriteYAML writes v in YAML format. The keys of the mappings are taken
+// from the json struct tags, so that the YAML and JSON dumps of the same
+// value have the same structure. Only structs, slices, pointers, strings,
+// booleans and numbers are supported.
+func WriteYAML(w io.Writer, v interface{}) error {
+	e := yamlEncoder{p: newPrinter(w)}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Struct {
+		e.mapping(e.fields(rv), "", "")
+	} else {
+		e.p.printf("---")
+		e.node(rv, "")
+	}
+	return e.p.flush()
+}
+
+type yamlEncoder struct {
+	p *printer
+}
+
+type yamlField struct {
+	key   string
+	value reflect.Value
+}
+
+// fields returns the fields of the struct v to be encoded.
+func (e *yamlEncoder) fields(v reflect.Value) []yamlField {
+	var fields []yamlField
+	t := v.Type()
+	for j := 0; j < t.NumField(); j++ {
+		sf := t.Field(j)
+		if sf.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		key, omitEmpty := sf.Name, false
+		if tag := sf.Tag.Get("json"); tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				key = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				omitEmpty = omitEmpty || opt == "omitempty"
+			}
+		}
+		fv := v.Field(j)
+		if omitEmpty && yamlIsEmpty(fv) {
+			continue
+		}
+		fields = append(fields, yamlField{key: key, value: fv})
+	}
+	return fields
+}
+
+func yamlIsEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	}
+	return false
+}
+
+// mapping writes the fields: the first one with the firstIndent,
+// the others with the indent.
+func (e *yamlEncoder) mapping(fields []yamlField, firstIndent, indent string) {
+	for j, f := range fields {
+		if j == 0 {
+			e.p.printf("%s%s:", firstIndent, f.key)
+		} else {
+			e.p.printf("%s%s:", indent, f.key)
+		}
+		e.node(f.value, indent)
+	}
+}
+
+// node writes the value following a "key:" or "-" at the given indent.
+func (e *yamlEncoder) node(v reflect.Value, indent string) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			e.p.printf(" null\n")
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := e.fields(v)
+		if len(fields) == 0 {
+			e.p.printf(" {}\n")
+			return
+		}
+		e.p.printf("\n")
+		e.mapping(fields, indent+"  ", indent+"  ")
+
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			e.p.printf(" []\n")
+			return
+		}
+		e.p.printf("\n")
+		itemIndent := indent + "  "
+		for j := 0; j < v.Len(); j++ {
+			item := reflect.Indirect(v.Index(j))
+			if item.Kind() == reflect.Struct {
+				if fields := e.fields(item); len(fields) > 0 {
+					e.mapping(fields, itemIndent+"- ", itemIndent+"  ")
+					continue
+				}
+			}
+			e.p.printf("%s-", itemIndent)
+			e.node(item, itemIndent+"  ")
+		}
+
+	case reflect.String:
+		b, _ := json.Marshal(v.String())
+		e.p.printf(" %s\n", b)
+	case reflect.Bool:
+		e.p.printf(" %t\n", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.p.printf(" %d\n", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.p.printf(" %d\n", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.p.printf(" %s\n", strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	default:
+		e.p.printf(" null\n")
+	}
+}
@@ -0,0 +1,176 @@
+// Package importer creates transactions from bank statements (CSV or OFX),
+// assigning each statement line to a target account by means of
+// configurable rules and of the import-map-bayes data stored by GnuCash.
+package importer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/classify"
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// How the target account of a line has been found
+const (
+	MatchNone    = ""
+	MatchRule    = "rule"
+	MatchBayes   = "bayes"
+	MatchDefault = "default"
+)
+
+// Importer type
+type Importer struct {
+	Book           *model.Book
+	Account        *model.Account // account of the statement
+	Rules          []*Rule
+	Classifier     *classify.Classifier // default: the bayes map of Account
+	BayesThreshold float64              // minimum probability of the bayes match
+	DefaultAccount *model.Account       // target of the unmatched lines (optional)
+}
+
+// Entry type: the result of the import of a statement line
+type Entry struct {
+	Line      *Line
+	Account   *model.Account // target account, nil if not found
+	MatchedBy string
+	Duplicate *model.Split // split already in the book, nil if the line is new
+}
+
+// Match finds the target account of each line of the statement and
+// detects the lines already present in the book.
+func (imp *Importer) Match(st *Statement) ([]*Entry, error) {
+	entries := make([]*Entry, 0, len(st.Lines))
+	used := map[*model.Split]bool{}
+	if imp.Classifier == nil {
+		imp.Classifier = classify.New(imp.Book, imp.Account)
+	}
+
+	for _, line := range st.Lines {
+		if line.Description == "" {
+			line.Description = line.Memo
+		}
+		e := &Entry{Line: line}
+		e.Duplicate = imp.findDuplicate(line, used)
+		if e.Duplicate != nil {
+			used[e.Duplicate] = true
+		} else {
+			var err error
+			if e.Account, e.MatchedBy, err = imp.findAccount(line); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// findDuplicate returns the split of the statement account with the same
+// FITID of the line. Splits without FITID (i.e. not imported) are
+// duplicates if they have the same date and amount of the line.
+func (imp *Importer) findDuplicate(line *Line, used map[*model.Split]bool) *model.Split {
+	day := line.Date.Format("2006-01-02")
+	for _, at := range imp.Account.AccountTransactionList {
+		s := at.Split
+		if used[s] {
+			continue
+		}
+		onlineID := s.Slots.Value("online_id")
+		if line.FITID != "" && onlineID == line.FITID {
+			return s
+		}
+		if onlineID == "" && at.Transaction.DatePosted.Format("2006-01-02") == day && numeric.Cmp(&s.Value, &line.Amount) == 0 {
+			return s
+		}
+	}
+	return nil
+}
+
+// findAccount returns the target account of the line.
+func (imp *Importer) findAccount(line *Line) (*model.Account, string, error) {
+	// 1. rules
+	for _, rule := range imp.Rules {
+		if rule.Match(line) {
+			acc := imp.Book.Accounts.ByFullName(rule.Account)
+			if acc == nil {
+				return nil, MatchNone, fmt.Errorf("Rule account not found: %s", rule.Account)
+			}
+			return acc, MatchRule, nil
+		}
+	}
+	// 2. import-map-bayes
+	if acc := imp.Classifier.Best(line.Description+" "+line.Memo, imp.BayesThreshold); acc != nil {
+		return acc, MatchBayes, nil
+	}
+	// 3. default account
+	if imp.DefaultAccount != nil {
+		return imp.DefaultAccount, MatchDefault, nil
+	}
+	return nil, MatchNone, nil
+}
+
+// gncTime formats t as a GnuCash timestamp
+func gncTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05 -0700")
+}
+
+// Transactions returns the new transactions of the entries that are not
+// duplicates and have a target account.
+func (imp *Importer) Transactions(entries []*Entry) ([]gncxml.Transaction, error) {
+	now := time.Now()
+	currency := imp.Book.Commodities.Get(imp.Account.Currency)
+	fraction := int64(currency.Fraction)
+
+	list := []gncxml.Transaction{}
+	for _, e := range entries {
+		if e.Duplicate != nil || e.Account == nil {
+			continue
+		}
+		if e.Account.Currency != imp.Account.Currency {
+			return nil, fmt.Errorf("Not Implemented: target account %s has a different commodity", e.Account.FullName())
+		}
+		amount, err := e.Line.Amount.ConvertDen(fraction)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid amount of %q: %s", e.Line.Description, err)
+		}
+		counter := numeric.Neg(&amount)
+		d := e.Line.Date
+		posted := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.Local)
+
+		split := gncxml.Split{
+			ID:              gncxml.NewGUID(),
+			ReconciledState: "n",
+			Value:           amount.FractionString(),
+			Quantity:        amount.FractionString(),
+			Memo:            e.Line.Memo,
+			AccountID:       imp.Account.ID,
+		}
+		if e.Line.FITID != "" {
+			split.Slots = []gncxml.Slot{{
+				Key:   "online_id",
+				Value: gncxml.SlotValue{Type: "string", Text: e.Line.FITID},
+			}}
+		}
+		list = append(list, gncxml.Transaction{
+			ID:            gncxml.NewGUID(),
+			CurrencySpace: currency.Space,
+			Currency:      currency.ID,
+			DatePosted:    gncTime(posted),
+			DateEntered:   gncTime(now),
+			Description:   e.Line.Description,
+			SplitList: []gncxml.Split{
+				split,
+				{
+					ID:              gncxml.NewGUID(),
+					ReconciledState: "n",
+					Value:           counter.FractionString(),
+					Quantity:        counter.FractionString(),
+					AccountID:       e.Account.ID,
+				},
+			},
+		})
+	}
+	return list, nil
+}
@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// DefaultDateWindow is the default number of days a statement line and
+// a split can differ to be matched.
+const DefaultDateWindow = 5
+
+// Reconciler type: matches the lines of a statement with the not yet
+// reconciled splits of an account.
+type Reconciler struct {
+	Account    *model.Account
+	DateWindow int // days; DefaultDateWindow if 0
+}
+
+// Pair type: a statement line matched with a split of the account
+type Pair struct {
+	Line  *Line
+	Split *model.AccountTransaction
+}
+
+// Reconciliation type: the result of the matching of a statement
+type Reconciliation struct {
+	Statement *Statement
+	Date      time.Time // statement date
+
+	Matched         []Pair
+	UnmatchedLines  []*Line
+	UnmatchedSplits []*model.AccountTransaction // splits posted up to the statement date
+
+	// StartingBalance is the reconciled balance of the account
+	StartingBalance numeric.Numeric
+	// EndingBalance is StartingBalance plus the matched splits
+	EndingBalance numeric.Numeric
+}
+
+// Balanced returns true if the ending balance equals the statement balance.
+func (r *Reconciliation) Balanced() bool {
+	if r.Statement.EndingBalance == nil {
+		return false
+	}
+	return numeric.Cmp(&r.EndingBalance, r.Statement.EndingBalance) == 0
+}
+
+// Difference returns the statement balance minus the ending balance.
+func (r *Reconciliation) Difference() numeric.Numeric {
+	if r.Statement.EndingBalance == nil {
+		return numeric.Numeric{}
+	}
+	return numeric.Sub(r.Statement.EndingBalance, &r.EndingBalance)
+}
+
+// days returns the absolute difference in days between a and b
+func days(a, b time.Time) int {
+	d := a.Sub(b)
+	if d < 0 {
+		d = -d
+	}
+	return int(d.Hours() / 24)
+}
+
+// Match matches each line of the statement with the split of the account
+// with the same FITID (online_id), or else with the same amount and the
+// closest date within the date window.
+// The statement date is the ending date of the statement, or the date of
+// its last line.
+func (rec *Reconciler) Match(st *Statement) (*Reconciliation, error) {
+	if rec.Account == nil {
+		return nil, errors.New("Missing account")
+	}
+	window := rec.DateWindow
+	if window == 0 {
+		window = DefaultDateWindow
+	}
+
+	r := &Reconciliation{Statement: st, Date: st.EndingDate}
+	if r.Date.IsZero() {
+		for _, line := range st.Lines {
+			if line.Date.After(r.Date) {
+				r.Date = line.Date
+			}
+		}
+	}
+
+	// candidate splits
+	var candidates []*model.AccountTransaction
+	for _, at := range rec.Account.AccountTransactionList {
+		if at.Split.Reconciled() {
+			q := at.Split.Quantity
+			r.StartingBalance.AddEqual(&q)
+			continue
+		}
+		if at.Split.ReconciledState != model.StateVoided {
+			candidates = append(candidates, at)
+		}
+	}
+
+	lines := make([]*Line, len(st.Lines))
+	copy(lines, st.Lines)
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Date.Before(lines[j].Date) })
+
+	used := map[*model.AccountTransaction]bool{}
+	for _, line := range lines {
+		var best *model.AccountTransaction
+		for _, at := range candidates {
+			if used[at] {
+				continue
+			}
+			if line.FITID != "" && at.Split.Slots.Value("online_id") == line.FITID {
+				best = at
+				break
+			}
+			if numeric.Cmp(&at.Split.Quantity, &line.Amount) != 0 {
+				continue
+			}
+			d := days(at.Transaction.DatePosted, line.Date)
+			if d <= window && (best == nil || d < days(best.Transaction.DatePosted, line.Date)) {
+				best = at
+			}
+		}
+		if best == nil {
+			r.UnmatchedLines = append(r.UnmatchedLines, line)
+			continue
+		}
+		used[best] = true
+		r.Matched = append(r.Matched, Pair{Line: line, Split: best})
+	}
+
+	r.EndingBalance.Set(&r.StartingBalance)
+	for _, p := range r.Matched {
+		q := p.Split.Split.Quantity
+		r.EndingBalance.AddEqual(&q)
+	}
+	for _, at := range candidates {
+		if !used[at] && !at.Transaction.DatePosted.After(r.Date) {
+			r.UnmatchedSplits = append(r.UnmatchedSplits, at)
+		}
+	}
+	return r, nil
+}
+
+// Apply marks the matched splits as reconciled at the statement date
+// in the raw GnuCash file. The statement must be balanced.
+func (r *Reconciliation) Apply(f *gncxml.File) error {
+	if r.Statement.EndingBalance == nil {
+		return errors.New("Missing statement ending balance")
+	}
+	if !r.Balanced() {
+		return errors.New("Statement not balanced")
+	}
+	d := r.Date
+	date := gncTime(time.Date(d.Year(), d.Month(), d.Day(), 23, 59, 59, 0, time.Local))
+	for _, p := range r.Matched {
+		if err := f.SetReconciled(p.Split.Split.ID, model.StateReconciled, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Rule type: assigns the lines matching the rule to the Account.
+// A line matches the rule if its description (or memo) matches the
+// Description regular expression and its amount is between Min and Max.
+type Rule struct {
+	Description *regexp.Regexp
+	Min         *numeric.Numeric // nil: no lower limit
+	Max         *numeric.Numeric // nil: no upper limit
+	Account     string           // account full name
+}
+
+// jsonRule is the JSON representation of a Rule
+type jsonRule struct {
+	Description string `json:"description"`
+	Min         string `json:"min"`
+	Max         string `json:"max"`
+	Account     string `json:"account"`
+}
+
+// ReadRules reads a list of rules in JSON format, e.g.
+//
+//	[
+//	  {"description": "(?i)benzina", "max": "0", "account": "Expenses:Auto:Fuel"},
+//	  {"description": "(?i)stipendio", "min": "1000", "account": "Income:Salary"}
+//	]
+func ReadRules(r io.Reader) ([]*Rule, error) {
+	var list []jsonRule
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*Rule, 0, len(list))
+	for j, jr := range list {
+		rule := &Rule{Account: jr.Account}
+		if jr.Account == "" {
+			return nil, fmt.Errorf("Rule %d: missing account", j+1)
+		}
+		if jr.Description != "" {
+			re, err := regexp.Compile(jr.Description)
+			if err != nil {
+				return nil, fmt.Errorf("Rule %d: %s", j+1, err)
+			}
+			rule.Description = re
+		}
+		if jr.Min != "" {
+			min, err := numeric.FromDecimal(jr.Min)
+			if err != nil {
+				return nil, fmt.Errorf("Rule %d: invalid min: %s", j+1, err)
+			}
+			rule.Min = &min
+		}
+		if jr.Max != "" {
+			max, err := numeric.FromDecimal(jr.Max)
+			if err != nil {
+				return nil, fmt.Errorf("Rule %d: invalid max: %s", j+1, err)
+			}
+			rule.Max = &max
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Match returns true if the line matches the rule.
+func (rule *Rule) Match(line *Line) bool {
+	if rule.Description != nil &&
+		!rule.Description.MatchString(line.Description) &&
+		!rule.Description.MatchString(line.Memo) {
+		return false
+	}
+	if rule.Min != nil && numeric.Cmp(&line.Amount, rule.Min) < 0 {
+		return false
+	}
+	if rule.Max != nil && numeric.Cmp(&line.Amount, rule.Max) > 0 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Statement type: the lines of a bank statement
+type Statement struct {
+	Currency      string
+	Lines         []*Line
+	EndingBalance *numeric.Numeric // nil if unknown
+	EndingDate    time.Time        // zero if unknown
+}
+
+// Line type: a line of a bank statement.
+// Positive amounts are deposits, negative amounts are withdrawals.
+type Line struct {
+	Date        time.Time
+	Amount      numeric.Numeric
+	Description string
+	Memo        string
+	FITID       string // financial institution transaction ID (optional)
+}
+
+// CSVFormat type: describes the layout of a CSV statement.
+// Columns are numbered from 1; a zero column is missing.
+type CSVFormat struct {
+	Delimiter          rune
+	DateFormat         string
+	DecimalSeparator   string
+	ThousandsSeparator string
+	Skip               int // number of header lines
+
+	DateColumn        int
+	DescriptionColumn int
+	AmountColumn      int // signed amount
+	DebitColumn       int // withdrawals, used if AmountColumn is 0
+	CreditColumn      int // deposits, used if AmountColumn is 0
+	MemoColumn        int
+	IDColumn          int
+}
+
+// DefaultCSVFormat is the default CSV statement format:
+// date, description, amount, with an header line.
+var DefaultCSVFormat = CSVFormat{
+	Delimiter:         ',',
+	DateFormat:        "2006-01-02",
+	DecimalSeparator:  ".",
+	Skip:              1,
+	DateColumn:        1,
+	DescriptionColumn: 2,
+	AmountColumn:      3,
+}
+
+// parseAmount parses an amount with the given separators.
+func parseAmount(s, decimalSep, thousandsSep string) (numeric.Numeric, error) {
+	s = strings.TrimSpace(s)
+	if thousandsSep != "" {
+		s = strings.Replace(s, thousandsSep, "", -1)
+	}
+	if decimalSep != "" && decimalSep != "." {
+		s = strings.Replace(s, decimalSep, ".", 1)
+	}
+	s = strings.TrimPrefix(s, "+")
+	if s == "" {
+		return numeric.Numeric{}, nil
+	}
+	return numeric.FromDecimal(s)
+}
+
+// ReadCSV reads a statement in CSV format.
+func ReadCSV(r io.Reader, format *CSVFormat) (*Statement, error) {
+	if format == nil {
+		format = &DefaultCSVFormat
+	}
+	cr := csv.NewReader(r)
+	if format.Delimiter != 0 {
+		cr.Comma = format.Delimiter
+	}
+	cr.FieldsPerRecord = -1
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Statement{}
+	for j, record := range records {
+		if j < format.Skip {
+			continue
+		}
+		row := j + 1
+		field := func(col int) string {
+			if col <= 0 || col > len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col-1])
+		}
+		if len(record) == 1 && field(1) == "" {
+			// skip empty line
+			continue
+		}
+
+		line := &Line{
+			Description: field(format.DescriptionColumn),
+			Memo:        field(format.MemoColumn),
+			FITID:       field(format.IDColumn),
+		}
+		line.Date, err = time.ParseInLocation(format.DateFormat, field(format.DateColumn), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid date at line %d: %s", row, err)
+		}
+		if format.AmountColumn > 0 {
+			line.Amount, err = parseAmount(field(format.AmountColumn), format.DecimalSeparator, format.ThousandsSeparator)
+		} else {
+			var debit, credit numeric.Numeric
+			debit, err = parseAmount(field(format.DebitColumn), format.DecimalSeparator, format.ThousandsSeparator)
+			if err == nil {
+				credit, err = parseAmount(field(format.CreditColumn), format.DecimalSeparator, format.ThousandsSeparator)
+			}
+			if debit.Sign() > 0 {
+				debit.NegEqual()
+			}
+			line.Amount = numeric.Add(&credit, &debit)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid amount at line %d: %s", row, err)
+		}
+		st.Lines = append(st.Lines, line)
+	}
+	return st, nil
+}
+
+// ofxTime parses an OFX date: YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]].
+func ofxTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 14 {
+		return time.ParseInLocation("20060102150405", s[:14], time.Local)
+	}
+	if len(s) >= 8 {
+		return time.ParseInLocation("20060102", s[:8], time.Local)
+	}
+	return time.Time{}, fmt.Errorf("Invalid OFX date: %q", s)
+}
+
+// ReadOFX reads a bank or credit card statement in OFX format.
+// Both the SGML (1.x) and the XML (2.x) versions are accepted.
+func ReadOFX(r io.Reader) (*Statement, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := string(data)
+	if idx := strings.Index(strings.ToUpper(s), "<OFX>"); idx >= 0 {
+		s = s[idx:]
+	}
+
+	st := &Statement{}
+	var (
+		line    *Line
+		path    []string // open aggregates
+		balance string
+		asOf    string
+	)
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			break
+		}
+		gt := strings.IndexByte(s[lt:], '>')
+		if gt < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(s[lt+1 : lt+gt]))
+		s = s[lt+gt+1:]
+
+		if strings.HasPrefix(tag, "/") {
+			// closing tag
+			tag = tag[1:]
+			for j := len(path) - 1; j >= 0; j-- {
+				if path[j] == tag {
+					path = path[:j]
+					break
+				}
+			}
+			if tag == "STMTTRN" && line != nil {
+				st.Lines = append(st.Lines, line)
+				line = nil
+			}
+			continue
+		}
+
+		// value of the element: text up to the next tag
+		next := strings.IndexByte(s, '<')
+		if next < 0 {
+			next = len(s)
+		}
+		value := strings.TrimSpace(xmlUnescape(s[:next]))
+		if value == "" {
+			// aggregate
+			path = append(path, tag)
+			if tag == "STMTTRN" {
+				line = &Line{}
+			}
+			continue
+		}
+
+		parent := ""
+		if len(path) > 0 {
+			parent = path[len(path)-1]
+		}
+		switch {
+		case tag == "CURDEF":
+			st.Currency = value
+		case parent == "LEDGERBAL" && tag == "BALAMT":
+			balance = value
+		case parent == "LEDGERBAL" && tag == "DTASOF":
+			asOf = value
+		case line != nil:
+			switch tag {
+			case "DTPOSTED":
+				line.Date, err = ofxTime(value)
+			case "TRNAMT":
+				line.Amount, err = parseAmount(value, ".", "")
+			case "FITID":
+				line.FITID = value
+			case "NAME", "PAYEE":
+				line.Description = value
+			case "MEMO":
+				line.Memo = value
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s in OFX transaction: %s", tag, err)
+			}
+		}
+	}
+
+	if balance != "" {
+		b, err := parseAmount(balance, ".", "")
+		if err != nil {
+			return nil, fmt.Errorf("Invalid OFX ledger balance: %s", err)
+		}
+		st.EndingBalance = &b
+	}
+	if asOf != "" {
+		if st.EndingDate, err = ofxTime(asOf); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// xmlUnescape replaces the predefined XML entities.
+var xmlUnescape = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&amp;", "&").Replace
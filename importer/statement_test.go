@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// date returns the local midnight of the day in the YYYY-MM-DD form
+func date(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// checkLines compares the date, amount and description of the lines
+func checkLines(t *testing.T, name string, lines []*Line, want []Line) {
+	if len(lines) != len(want) {
+		t.Fatalf("%s: %d lines, want %d", name, len(lines), len(want))
+	}
+	for j, w := range want {
+		l := lines[j]
+		if !l.Date.Equal(w.Date) || numeric.Cmp(&l.Amount, &w.Amount) != 0 ||
+			l.Description != w.Description || l.Memo != w.Memo || l.FITID != w.FITID {
+			t.Errorf("%s: line %d = {%s %s %q %q %q}, want {%s %s %q %q %q}", name, j+1,
+				l.Date.Format("2006-01-02"), l.Amount.DecimalString(2), l.Description, l.Memo, l.FITID,
+				w.Date.Format("2006-01-02"), w.Amount.DecimalString(2), w.Description, w.Memo, w.FITID)
+		}
+	}
+}
+
+// amount returns the numeric of the decimal string
+func amount(s string) numeric.Numeric {
+	n, err := numeric.FromDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		s, decimalSep, thousandsSep string
+		want                        string
+	}{
+		{"1234.56", ".", "", "1234.56"},
+		{" +10 ", ".", "", "10"},
+		{"-1.234,50", ",", ".", "-1234.5"},
+		{"1,234.50", ".", ",", "1234.5"},
+		{"-20", ",", ".", "-20"},
+		{"", ".", "", "0"},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.s, tt.decimalSep, tt.thousandsSep)
+		if err != nil {
+			t.Errorf("parseAmount(%q): unexpected error: %v", tt.s, err)
+			continue
+		}
+		if want := amount(tt.want); numeric.Cmp(&got, &want) != 0 {
+			t.Errorf("parseAmount(%q, %q, %q) = %s, want %s", tt.s, tt.decimalSep, tt.thousandsSep, got.DecimalString(2), tt.want)
+		}
+	}
+	for _, s := range []string{"abc", "1.2.3", "12-"} {
+		if _, err := parseAmount(s, ".", ""); err == nil {
+			t.Errorf("parseAmount(%q): want an error", s)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "Date,Description,Amount\n2015-02-03,Benzina ENI,-50.00\n\n2015-02-06,\"Bonifico, stipendio\",100\n"
+	st, err := ReadCSV(strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLines(t, "default format", st.Lines, []Line{
+		{Date: date("2015-02-03"), Amount: amount("-50"), Description: "Benzina ENI"},
+		{Date: date("2015-02-06"), Amount: amount("100"), Description: "Bonifico, stipendio"},
+	})
+
+	// Italian format with debit and credit columns
+	format := &CSVFormat{
+		Delimiter:          ';',
+		DateFormat:         "02/01/2006",
+		DecimalSeparator:   ",",
+		ThousandsSeparator: ".",
+		DateColumn:         1,
+		DescriptionColumn:  2,
+		DebitColumn:        3,
+		CreditColumn:       4,
+		MemoColumn:         5,
+		IDColumn:           6,
+	}
+	data = "03/02/2015;Benzina ENI;50,00;;pieno;X1\n05/02/2015;Rifornimento;-1.234,50;;;X2\n06/02/2015;Stipendio;;2.000,00;gennaio;X3\n"
+	if st, err = ReadCSV(strings.NewReader(data), format); err != nil {
+		t.Fatal(err)
+	}
+	checkLines(t, "debit and credit columns", st.Lines, []Line{
+		{Date: date("2015-02-03"), Amount: amount("-50"), Description: "Benzina ENI", Memo: "pieno", FITID: "X1"},
+		{Date: date("2015-02-05"), Amount: amount("-1234.5"), Description: "Rifornimento", FITID: "X2"},
+		{Date: date("2015-02-06"), Amount: amount("2000"), Description: "Stipendio", Memo: "gennaio", FITID: "X3"},
+	})
+
+	errors := []string{
+		"Date,Description,Amount\n03/02/2015,Benzina,-50\n",
+		"Date,Description,Amount\n2015-02-03,Benzina,abc\n",
+		"Date,Description,Amount\n2015-02-03,\"Benzina,-50\n",
+	}
+	for _, data := range errors {
+		if _, err := ReadCSV(strings.NewReader(data), nil); err == nil {
+			t.Errorf("ReadCSV(%q): want an error", data)
+		}
+	}
+}
+
+func TestOFXTime(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"20150301", date("2015-03-01")},
+		{"20150203120000", date("2015-02-03").Add(12 * time.Hour)},
+		{"20150203120000.000[-5:EST]", date("2015-02-03").Add(12 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := ofxTime(tt.s)
+		if err != nil {
+			t.Errorf("ofxTime(%q): unexpected error: %v", tt.s, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ofxTime(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+	for _, s := range []string{"", "2015", "2015-03-01"} {
+		if _, err := ofxTime(s); err == nil {
+			t.Errorf("ofxTime(%q): want an error", s)
+		}
+	}
+}
+
+func TestReadOFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20150203<TRNAMT>-50.00<FITID>X1<NAME>Benzina ENI<MEMO>pieno
+</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20150301<TRNAMT>10.5<FITID>X2<NAME>Rimborso &amp; altro
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>4010.50<DTASOF>20150301</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211"?>
+<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+<CURDEF>EUR</CURDEF>
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20150203</DTPOSTED><TRNAMT>-50.00</TRNAMT><FITID>X1</FITID><NAME>Benzina ENI</NAME><MEMO>pieno</MEMO></STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20150301</DTPOSTED><TRNAMT>10.5</TRNAMT><FITID>X2</FITID><PAYEE>Rimborso &amp; altro</PAYEE></STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>4010.50</BALAMT><DTASOF>20150301</DTASOF></LEDGERBAL>
+<AVAILBAL><BALAMT>9999</BALAMT><DTASOF>20150302</DTASOF></AVAILBAL>
+</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>
+`
+	for name, data := range map[string]string{"SGML": sgml, "XML": xml} {
+		st, err := ReadOFX(strings.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		checkLines(t, name, st.Lines, []Line{
+			{Date: date("2015-02-03"), Amount: amount("-50"), Description: "Benzina ENI", Memo: "pieno", FITID: "X1"},
+			{Date: date("2015-03-01"), Amount: amount("10.5"), Description: "Rimborso & altro", FITID: "X2"},
+		})
+		if st.Currency != "EUR" {
+			t.Errorf("%s: currency %q, want EUR", name, st.Currency)
+		}
+		if want := amount("4010.50"); st.EndingBalance == nil || numeric.Cmp(st.EndingBalance, &want) != 0 {
+			t.Errorf("%s: ending balance %v, want 4010.50", name, st.EndingBalance)
+		}
+		if !st.EndingDate.Equal(date("2015-03-01")) {
+			t.Errorf("%s: ending date %s, want 2015-03-01", name, st.EndingDate)
+		}
+	}
+
+	if _, err := ReadOFX(strings.NewReader("<OFX><STMTTRN><TRNAMT>abc</STMTTRN></OFX>")); err == nil {
+		t.Error("ReadOFX with an invalid amount: want an error")
+	}
+}
+
+func TestReadRules(t *testing.T) {
+	data := `[
+	  {"description": "(?i)benzina", "max": "0", "account": "Uscite:Auto"},
+	  {"description": "(?i)stipendio", "min": "1000", "account": "Entrate:Stipendio"},
+	  {"min": "-10", "max": "10", "account": "Uscite:Varie"}
+	]`
+	rules, err := ReadRules(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		line Line
+		want string // account of the first matching rule
+	}{
+		{Line{Description: "BENZINA ENI", Amount: amount("-50")}, "Uscite:Auto"},
+		{Line{Description: "Rimborso", Memo: "benzina", Amount: amount("-5")}, "Uscite:Auto"},
+		{Line{Description: "Benzina", Amount: amount("5")}, "Uscite:Varie"},
+		{Line{Description: "Stipendio", Amount: amount("2000")}, "Entrate:Stipendio"},
+		{Line{Description: "Stipendio", Amount: amount("500")}, ""},
+		{Line{Description: "Pizzeria", Amount: amount("-20")}, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, rule := range rules {
+			if rule.Match(&tt.line) {
+				got = rule.Account
+				break
+			}
+		}
+		if got != tt.want {
+			t.Errorf("line %q %s matches %q, want %q", tt.line.Description, tt.line.Amount.DecimalString(2), got, tt.want)
+		}
+	}
+
+	errors := []string{
+		`[{"description": "x"}]`,
+		`[{"description": "(", "account": "A"}]`,
+		`[{"min": "abc", "account": "A"}]`,
+		`[{"max": "1,5", "account": "A"}]`,
+		`{"account": "A"}`,
+	}
+	for _, data := range errors {
+		if _, err := ReadRules(strings.NewReader(data)); err == nil {
+			t.Errorf("ReadRules(%s): want an error", data)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package index implements an in-memory full-text index of the
+// descriptions, memos and notes of the transactions of a book.
+//
+// The text is split in tokens of letters and digits, folded to lower case
+// and without accents, so that "Città" is found by "citta". The tokens of
+// a search are matched as prefixes and all of them must be found.
+package index
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/text"
+)
+
+// Index type
+type Index struct {
+	terms        []string         // sorted terms
+	postings     map[string][]int // term -> sorted positions in transactions
+	transactions model.Transactions
+}
+
+// Tokenize splits s in case and accent insensitive tokens.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(text.RemoveAccents(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// New builds the index of the transactions of the book.
+func New(book *model.Book) *Index {
+	idx := &Index{
+		postings:     map[string][]int{},
+		transactions: book.Transactions,
+	}
+	for pos, t := range book.Transactions {
+		fields := []string{t.Description, t.Notes()}
+		for _, s := range t.Splits {
+			fields = append(fields, s.Memo)
+		}
+		for _, field := range fields {
+			for _, term := range Tokenize(field) {
+				list := idx.postings[term]
+				// transactions are visited in order: check only the last one
+				if len(list) == 0 || list[len(list)-1] != pos {
+					idx.postings[term] = append(list, pos)
+				}
+			}
+		}
+	}
+	idx.terms = make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+	return idx
+}
+
+// Len returns the number of distinct terms of the index.
+func (idx *Index) Len() int {
+	return len(idx.terms)
+}
+
+// prefixMatch returns the set of the positions of the transactions
+// containing a term starting with prefix.
+func (idx *Index) prefixMatch(prefix string) map[int]bool {
+	set := map[int]bool{}
+	for j := sort.SearchStrings(idx.terms, prefix); j < len(idx.terms) && strings.HasPrefix(idx.terms[j], prefix); j++ {
+		for _, pos := range idx.postings[idx.terms[j]] {
+			set[pos] = true
+		}
+	}
+	return set
+}
+
+// Search returns the transactions containing all the tokens of the query
+// (as prefixes of the indexed terms), most recent first.
+func (idx *Index) Search(query string) model.Transactions {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return model.Transactions{}
+	}
+
+	var found map[int]bool
+	for _, token := range tokens {
+		set := idx.prefixMatch(token)
+		if found == nil {
+			found = set
+			continue
+		}
+		for pos := range found {
+			if !set[pos] {
+				delete(found, pos)
+			}
+		}
+	}
+
+	positions := make([]int, 0, len(found))
+	for pos := range found {
+		positions = append(positions, pos)
+	}
+	// transactions are sorted by date posted: the greater position is the more recent
+	sort.Sort(sort.Reverse(sort.IntSlice(positions)))
+
+	res := make(model.Transactions, len(positions))
+	for j, pos := range positions {
+		res[j] = idx.transactions[pos]
+	}
+	return res
+}
+
+// SearchIDs returns the IDs of the transactions found by Search.
+func (idx *Index) SearchIDs(query string) []string {
+	list := idx.Search(query)
+	ids := make([]string, len(list))
+	for j, t := range list {
+		ids[j] = t.ID
+	}
+	return ids
+}
@@ -12,15 +12,19 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/mmbros/gnucash-viewer/business"
 	"github.com/mmbros/gnucash-viewer/model"
 	"github.com/mmbros/gnucash-viewer/numeric"
 	gncxml "github.com/mmbros/gnucash-viewer/xml"
 )
 
 var gnucashPath = flag.String("gnucash-file", "data/data.gnucash", "GnuCash file path")
+var bookID = flag.String("book", "", "ID of the book to use if the file contains multiple books")
+var lenient = flag.Bool("lenient", false, "skip the invalid records of the GnuCash file, reporting them on stderr")
 
 // --------------------------------------------------------------------------
 
@@ -69,7 +73,140 @@ func StringPad(s string, n int, pad string) string {
 	return s + strings.Repeat(pad, n-L)
 }
 
+// command type. The commands using the business data set runBusiness
+// instead of run.
+type command struct {
+	name        string
+	usage       string
+	run         func(book *model.Book, args []string) error
+	runBusiness func(biz *business.Business, args []string) error
+}
+
+// commands is the list of the available sub-commands
+var commands = []*command{
+	cmdExport,
+	cmdDump,
+	cmdImport,
+	cmdSuggest,
+	cmdSearch,
+	cmdFind,
+	cmdReconciliation,
+	cmdReconcile,
+	cmdValidate,
+	cmdScheduled,
+	cmdForecast,
+	cmdBudget,
+	cmdGains,
+	cmdCurrencyGains,
+	cmdPortfolio,
+	cmdPrices,
+	cmdInvoices,
+	cmdAging,
+	cmdTax,
+	cmdServe,
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] [command [arguments]]\n\nOptions:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", cmd.usage)
+	}
+}
+
+// loadBook loads the book of the file. It also returns the XML book,
+// used to load the business data.
+func loadBook(path string) (*model.Book, *gncxml.Book, error) {
+	gnc, err := gncxml.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	errs := model.NewErrorCollector(*lenient)
+	f, err := model.LoadFile(gnc, errs)
+	if err != nil {
+		return nil, nil, err
+	}
+	printSkipped(errs)
+
+	j := 0
+	if *bookID != "" {
+		for j = 0; j < len(f.Books) && f.Books[j].ID != *bookID; j++ {
+		}
+		if j == len(f.Books) {
+			return nil, nil, fmt.Errorf("Book not found: %s", *bookID)
+		}
+	} else if len(f.Books) > 1 {
+		ids := make([]string, len(f.Books))
+		for j, book := range f.Books {
+			ids[j] = book.ID
+		}
+		return nil, nil, fmt.Errorf("Multiple BOOK: use -book with one of %s", strings.Join(ids, ", "))
+	}
+	return f.Books[j], &gnc.Books[j], nil
+}
+
+// loadBusiness loads the business data of the book
+func loadBusiness(xmlBook *gncxml.Book, book *model.Book) (*business.Business, error) {
+	errs := model.NewErrorCollector(*lenient)
+	biz, err := business.Load(xmlBook, book, errs)
+	if err != nil {
+		return nil, err
+	}
+	printSkipped(errs)
+	return biz, nil
+}
+
+// printSkipped prints the records skipped in lenient mode
+func printSkipped(errs *model.ErrorCollector) {
+	for _, e := range errs.Errors() {
+		fmt.Fprintf(os.Stderr, "skipped %s %s: %s\n", e.Object, e.ID, e)
+	}
+}
+
+// runCommand loads the book and runs the command
+func runCommand(cmd *command, args []string) error {
+	book, xmlBook, err := loadBook(*gnucashPath)
+	if err != nil {
+		return err
+	}
+	if cmd.runBusiness == nil {
+		return cmd.run(book, args)
+	}
+	biz, err := loadBusiness(xmlBook, book)
+	if err != nil {
+		return err
+	}
+	return cmd.runBusiness(biz, args)
+}
+
 func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		mainDefault()
+		return
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := runCommand(cmd, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func mainDefault() {
 	defer timeTrack(time.Now(), "task duration:")
 
 	gnc, err := gncxml.ReadFile(*gnucashPath)
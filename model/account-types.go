@@ -2,6 +2,7 @@ package model
 
 // AccountType type
 type AccountType struct {
+	code         string
 	label        string
 	root         bool
 	invertValues bool
@@ -30,6 +31,12 @@ var AccountTypes = map[string]AccountType{
 		plusLabel:  "Increase",
 		minusLabel: "Decrease",
 	},
+	"PAYABLE": AccountType{
+		label:        "Payable",
+		invertValues: true,
+		plusLabel:    "Payment",
+		minusLabel:   "Bill",
+	},
 	"EXPENSE": AccountType{
 		label:      "Expense",
 		plusLabel:  "Expense",
@@ -62,4 +69,55 @@ var AccountTypes = map[string]AccountType{
 		plusLabel:  "Increase",
 		minusLabel: "Decrease",
 	},
+	"STOCK": AccountType{
+		label:      "Stock",
+		plusLabel:  "Buy",
+		minusLabel: "Sell",
+	},
+	"MUTUAL": AccountType{
+		label:      "Mutual Fund",
+		plusLabel:  "Buy",
+		minusLabel: "Sell",
+	},
+	"TRADING": AccountType{
+		label:      "Trading",
+		plusLabel:  "Increase",
+		minusLabel: "Decrease",
+	},
+}
+
+func init() {
+	// initialize the code of each AccountType
+	for code, t := range AccountTypes {
+		t.code = code
+		AccountTypes[code] = t
+	}
+}
+
+// Code returns the GnuCash code of the account type (e.g. "BANK")
+func (t *AccountType) Code() string {
+	return t.code
+}
+
+// Label returns the label of the account type
+func (t *AccountType) Label() string {
+	return t.label
+}
+
+// Labels returns the labels of the plus and minus values
+func (t *AccountType) Labels() (plus, minus string) {
+	plus, minus = t.plusLabel, t.minusLabel
+	if plus == "" {
+		plus = "Increase"
+	}
+	if minus == "" {
+		minus = "Decrease"
+	}
+	return
+}
+
+// InvertValues returns true if the balances of the accounts of this type
+// are shown with inverted sign (e.g. incomes are negative values).
+func (t *AccountType) InvertValues() bool {
+	return t.invertValues
 }
@@ -10,10 +10,14 @@ import (
 	gncxml "github.com/mmbros/gnucash-viewer/xml"
 )
 
+// AccountSeparator is the separator used in account full names
+const AccountSeparator = ":"
+
 // Accounts type
 type Accounts struct {
-	Root *Account
-	Map  map[string]*Account
+	Root          *Account   // main root account
+	TemplateRoots []*Account // root accounts of the template-transactions section
+	Map           map[string]*Account
 }
 
 // Account type
@@ -23,12 +27,16 @@ type Account struct {
 	Name                   string
 	Description            string
 	Currency               string
+	Slots                  Slots
+	Lots                   []*Lot
 	Parent                 *Account
 	Children               []*Account
 	AccountTransactionList []*AccountTransaction
 }
 
-// AccountTransaction type
+// AccountTransaction type: a split of the account. The plus and minus
+// values and the balance are in the commodity of the account, i.e. they
+// are computed from the split quantities.
 type AccountTransaction struct {
 	Transaction *Transaction
 	Split       *Split
@@ -41,7 +49,7 @@ func newAccountFromXML(xmlAccount *gncxml.Account) (*Account, error) {
 	// check account type
 	accType, ok := AccountTypes[xmlAccount.Type]
 	if !ok {
-		return nil, fmt.Errorf("Invalid AccountType: %s", xmlAccount.Type)
+		return nil, recordError("Account", xmlAccount.ID, "Invalid AccountType: %s", xmlAccount.Type)
 	}
 
 	// initialize Account object
@@ -51,12 +59,17 @@ func newAccountFromXML(xmlAccount *gncxml.Account) (*Account, error) {
 		Name:        xmlAccount.Name,
 		Description: xmlAccount.Description,
 		Currency:    xmlAccount.Currency,
+		Slots:       newSlotsFromXML(xmlAccount.Slots),
 	}
+	account.Lots = newLotsFromXML(xmlAccount.Lots, &account)
 
 	return &account, nil
 }
 
-func newAccountsFromXML(xmlAccountList []gncxml.Account) (*Accounts, error) {
+// newAccountsFromXML returns the accounts of the book or, if template is
+// true, the template accounts of the scheduled transactions, whose root
+// accounts are all TemplateRoots.
+func newAccountsFromXML(xmlAccountList []gncxml.Account, template bool, errs *errorCollector) (*Accounts, error) {
 	// step 0: allocate Accounts object
 	a := &Accounts{Map: map[string]*Account{}}
 
@@ -65,13 +78,20 @@ func newAccountsFromXML(xmlAccountList []gncxml.Account) (*Accounts, error) {
 
 		// check account unique id
 		if _, ok := a.Map[xmlAccount.ID]; ok {
-			return nil, fmt.Errorf("Multiple accounts with same ID: %s", xmlAccount.ID)
+			err := recordError("Account", xmlAccount.ID, "Multiple accounts with same ID: %s", xmlAccount.ID)
+			if err = errs.add(err); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
 		// initialize account
 		account, err := newAccountFromXML(&xmlAccount)
 		if err != nil {
-			return nil, err
+			if err = errs.add(err); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
 		// add Account object to Accounts.Map
@@ -81,31 +101,66 @@ func newAccountsFromXML(xmlAccountList []gncxml.Account) (*Accounts, error) {
 	// step 2: initilize root account and parent/children fields
 	for _, xmlAccount := range xmlAccountList {
 		account := a.Map[xmlAccount.ID]
+		if account == nil || account.Parent != nil || a.isRoot(account) {
+			// skipped or duplicated account
+			continue
+		}
 
+		var err error
 		if len(xmlAccount.ParentID) == 0 {
 			// found root account
-			if xmlAccount.Type != "ROOT" {
-				return nil, fmt.Errorf("Account of type ROOT can't have parent: Account.ID = %s", xmlAccount.ID)
+			switch {
+			case xmlAccount.Type != "ROOT":
+				err = recordError("Account", xmlAccount.ID, "Account of type ROOT can't have parent: Account.ID = %s", xmlAccount.ID)
+			case template:
+				a.TemplateRoots = append(a.TemplateRoots, account)
+			case a.Root != nil:
+				err = recordError("Account", xmlAccount.ID, "Multiple ROOT account: %s and %s", a.Root.ID, xmlAccount.ID)
+			default:
+				a.Root = account
 			}
-			if a.Root != nil {
-				return nil, errors.New("Not Implemented: multiple ROOT account")
-			}
-			a.Root = account
 
 		} else {
 			// not root account: set parent and children
 
 			parent := a.Map[xmlAccount.ParentID]
 			if parent == nil {
-				return nil, fmt.Errorf("Parent account not found: ParentID = %s", xmlAccount.ParentID)
+				err = recordError("Account", xmlAccount.ID, "Parent account not found: ParentID = %s", xmlAccount.ParentID)
+			} else {
+				account.Parent = parent
+				parent.Children = append(parent.Children, account)
 			}
+		}
+		if err != nil {
+			if err = errs.add(err); err != nil {
+				return nil, err
+			}
+			delete(a.Map, xmlAccount.ID)
+		}
+	}
+
+	if a.Root == nil && len(a.TemplateRoots) == 0 {
+		return nil, errors.New("ROOT account not found")
+	}
 
-			account.Parent = parent
-			parent.Children = append(parent.Children, account)
+	// step 3: in lenient mode, remove the accounts not connected to a
+	// root (i.e. the descendants of the skipped accounts)
+	if errs.lenient {
+		reachable := map[*Account]bool{}
+		mark := func(acc *Account, level int) { reachable[acc] = true }
+		a.Walk(mark)
+		for _, root := range a.TemplateRoots {
+			auxWalk(root, 0, mark)
+		}
+		for id, account := range a.Map {
+			if !reachable[account] {
+				errs.add(recordError("Account", id, "Account not connected to the ROOT account: %s", account.Name))
+				delete(a.Map, id)
+			}
 		}
 	}
 
-	// step 3: sort each account.children by name
+	// step 4: sort each account.children by name
 	for _, account := range a.Map {
 		sort.Sort(byAccountName(account.Children))
 	}
@@ -143,6 +198,37 @@ func (accounts *Accounts) PrintTree(indent string) {
 	auxPrintTree(accounts.Root, 0, indent)
 }
 
+// isRoot returns true if the account is the main or a template root
+func (accounts *Accounts) isRoot(act *Account) bool {
+	if act == accounts.Root {
+		return true
+	}
+	for _, root := range accounts.TemplateRoots {
+		if act == root {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn for each account of the tree, in depth-first order
+// starting from the main root account (level 0).
+// Children are visited in name order.
+func (accounts *Accounts) Walk(fn func(a *Account, level int)) {
+	if (accounts == nil) || (accounts.Root == nil) {
+		return
+	}
+	auxWalk(accounts.Root, 0, fn)
+}
+
+// auxWalk is a Walk auxiliary function
+func auxWalk(act *Account, level int, fn func(a *Account, level int)) {
+	fn(act, level)
+	for _, child := range act.Children {
+		auxWalk(child, level+1, fn)
+	}
+}
+
 // ByName return the account with the given name
 func (accounts *Accounts) ByName(name string) *Account {
 	for _, acc := range accounts.Map {
@@ -153,8 +239,44 @@ func (accounts *Accounts) ByName(name string) *Account {
 	return nil
 }
 
+// ByFullName return the account with the given full name
+func (accounts *Accounts) ByFullName(fullName string) *Account {
+	for _, acc := range accounts.Map {
+		if acc.FullName() == fullName {
+			return acc
+		}
+	}
+	return nil
+}
+
+// FullName returns the colon-separated path of the account from the
+// top level account, without the root account (e.g. "Expenses:Auto:Fuel").
+func (a *Account) FullName() string {
+	if a.Parent == nil {
+		return ""
+	}
+	if a.Parent.Parent == nil {
+		return a.Name
+	}
+	return a.Parent.FullName() + AccountSeparator + a.Name
+}
+
+// Placeholder returns true if the account is a placeholder,
+// i.e. it can't have splits.
+func (a *Account) Placeholder() bool {
+	return a.Slots.Value("placeholder") == "true"
+}
+
+// Hidden returns true if the account is hidden.
+func (a *Account) Hidden() bool {
+	return a.Slots.Value("hidden") == "true"
+}
+
 // Balance returns the balance of the account
 func (a *Account) Balance() numeric.Numeric {
+	if len(a.AccountTransactionList) == 0 {
+		return numeric.Numeric{}
+	}
 	return a.AccountTransactionList[len(a.AccountTransactionList)-1].Balance
 }
 
@@ -166,14 +288,17 @@ func (accounts *Accounts) postInit(transactions Transactions) {
 			at := AccountTransaction{Transaction: t, Split: s}
 			a := s.Account
 			a.AccountTransactionList = append(a.AccountTransactionList, &at)
+			if s.Lot != nil {
+				s.Lot.AccountTransactionList = append(s.Lot.AccountTransactionList, &at)
+			}
 		}
 	}
-	// initialize account balance
+	// initialize account balance, in the commodity of the account
 	for _, a := range accounts.Map {
 		var balance numeric.Numeric
 		for _, at := range a.AccountTransactionList {
 
-			v := at.Split.Value
+			v := at.Split.Quantity
 			balance.AddEqual(&v)
 			at.Balance.Set(&balance)
 
@@ -197,3 +322,14 @@ func (at *AccountTransaction) Description() string {
 	}
 	return at.Transaction.Description
 }
+
+// CounterSplits returns the splits of the transaction other than at.Split.
+func (at *AccountTransaction) CounterSplits() []*Split {
+	splits := make([]*Split, 0, len(at.Transaction.Splits)-1)
+	for _, s := range at.Transaction.Splits {
+		if s != at.Split {
+			splits = append(splits, s)
+		}
+	}
+	return splits
+}
@@ -8,35 +8,92 @@ import (
 
 // Book type
 type Book struct {
+	ID           string
+	Slots        Slots
+	Commodities  Commodities
+	Prices       Prices
 	Accounts     *Accounts
 	Transactions Transactions
+	Template     *Template
+
+	ScheduledTransactions ScheduledTransactions
+	Budgets               Budgets
 }
 
-// NewBook function
+// Template type: the template accounts and transactions of the
+// scheduled transactions. The template accounts have their own tree,
+// whose roots are in Accounts.TemplateRoots.
+type Template struct {
+	Accounts     *Accounts
+	Transactions Transactions
+}
+
+// NewBook function: strict load of the only book of the file,
+// the first invalid record is an error
 func NewBook(gnc *gncxml.Gnc) (*Book, error) {
+	if err := checkSingleBook(gnc); err != nil {
+		return nil, err
+	}
+	return newBookFromXML(&gnc.Books[0], &errorCollector{})
+}
+
+// NewBookLenient loads the only book of the file skipping the invalid
+// records. The returned error is nil or a LoadErrors with all the invalid
+// records; in the latter case the book is usable all the same. A nil book
+// is returned only for errors preventing the load of the whole book.
+func NewBookLenient(gnc *gncxml.Gnc) (*Book, error) {
+	if err := checkSingleBook(gnc); err != nil {
+		return nil, err
+	}
+	errs := &errorCollector{lenient: true}
+	book, err := newBookFromXML(&gnc.Books[0], errs)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs.list) > 0 {
+		return book, errs.list
+	}
+	return book, nil
+}
+
+// checkSingleBook checks that the file contains exactly one book
+func checkSingleBook(gnc *gncxml.Gnc) error {
 	if gnc == nil {
-		return nil, errors.New("GNC must be not nil")
+		return errors.New("GNC must be not nil")
 	}
 	switch len(gnc.Books) {
 	case 0:
-		return nil, errors.New("BOOK not found")
+		return errors.New("BOOK not found")
 	case 1:
-		break
-	default:
-		return nil, errors.New("Not Implemented: multiple BOOK")
+		return nil
 	}
-	xmlBook := gnc.Books[0]
-	book := Book{}
+	return errors.New("Multiple BOOK: use NewFile")
+}
+
+func newBookFromXML(xmlBook *gncxml.Book, errs *errorCollector) (*Book, error) {
+	book := Book{ID: xmlBook.ID, Slots: newSlotsFromXML(xmlBook.Slots)}
 	var err error
 
+	// init Commodities
+	book.Commodities, err = newCommoditiesFromXML(xmlBook.CommodityList, errs)
+	if err != nil {
+		return nil, err
+	}
+
+	// init Prices
+	book.Prices, err = newPricesFromXML(xmlBook.PriceList, errs)
+	if err != nil {
+		return nil, err
+	}
+
 	// init Accounts
-	book.Accounts, err = newAccountsFromXML(xmlBook.AccountList)
+	book.Accounts, err = newAccountsFromXML(xmlBook.AccountList, false, errs)
 	if err != nil {
 		return nil, err
 	}
 
 	// init Transactions
-	book.Transactions, err = newTransactionsFromXML(xmlBook.TransactionList, book.Accounts)
+	book.Transactions, err = newTransactionsFromXML(xmlBook.TransactionList, book.Accounts, errs)
 	if err != nil {
 		return nil, err
 	}
@@ -44,5 +101,46 @@ func NewBook(gnc *gncxml.Gnc) (*Book, error) {
 	// post-init Accounts
 	book.Accounts.postInit(book.Transactions)
 
+	// init Template
+	book.Template, err = newTemplateFromXML(xmlBook.TemplateList, errs)
+	if err != nil {
+		return nil, err
+	}
+
+	// init Budgets
+	book.Budgets, err = newBudgetsFromXML(xmlBook.BudgetList, book.Accounts, errs)
+	if err != nil {
+		return nil, err
+	}
+
+	// init ScheduledTransactions
+	book.ScheduledTransactions, err = newScheduledTransactionsFromXML(xmlBook.ScheduledTransactionList, &book, errs)
+	if err != nil {
+		return nil, err
+	}
+
 	return &book, nil
 }
+
+func newTemplateFromXML(xmlTemplateList []gncxml.Template, errs *errorCollector) (*Template, error) {
+	var xmlAccountList []gncxml.Account
+	var xmlTransactionList []gncxml.Transaction
+	for _, xmlTemplate := range xmlTemplateList {
+		xmlAccountList = append(xmlAccountList, xmlTemplate.AccountList...)
+		xmlTransactionList = append(xmlTransactionList, xmlTemplate.TransactionList...)
+	}
+
+	t := &Template{Accounts: &Accounts{Map: map[string]*Account{}}, Transactions: Transactions{}}
+	if len(xmlAccountList) == 0 {
+		return t, nil
+	}
+	var err error
+	if t.Accounts, err = newAccountsFromXML(xmlAccountList, true, errs); err != nil {
+		return nil, err
+	}
+	if t.Transactions, err = newTransactionsFromXML(xmlTransactionList, t.Accounts, errs); err != nil {
+		return nil, err
+	}
+	t.Accounts.postInit(t.Transactions)
+	return t, nil
+}
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// Budgets type
+type Budgets []*Budget
+
+// Budget type. The amounts are in the natural sign of each account,
+// i.e. inverted for the accounts whose type has inverted values
+// (income, liability and equity): a positive income budget is an
+// expected income.
+type Budget struct {
+	ID          string
+	Name        string
+	Description string
+	NumPeriods  int
+	Recurrence  Recurrence
+	Amounts     map[*Account]map[int]numeric.Numeric // by account and period
+}
+
+// BudgetPeriod type: the time interval [Start, End) of a budget period
+type BudgetPeriod struct {
+	Start time.Time
+	End   time.Time // zero if the period is open-ended
+}
+
+// Contains returns true if the day d is in the period.
+func (p BudgetPeriod) Contains(d time.Time) bool {
+	return !d.Before(p.Start) && (p.End.IsZero() || d.Before(p.End))
+}
+
+// BudgetRow type: budget and actual amounts of an account, including its
+// sub-accounts, for each period of a budget
+type BudgetRow struct {
+	Account *Account
+	Level   int // 1 for the top level accounts
+	Budget  []numeric.Numeric
+	Actual  []numeric.Numeric
+}
+
+// BudgetReport type: the budget vs actual report of a budget
+type BudgetReport struct {
+	Budget  *Budget
+	Periods []BudgetPeriod
+	Rows    []*BudgetRow // in account tree order
+}
+
+func newBudgetFromXML(xmlBudget *gncxml.Budget, accounts *Accounts) (*Budget, error) {
+	numPeriods, err := atoi(xmlBudget.NumPeriods)
+	if err != nil {
+		return nil, formatError("Budget", "NumPeriods", xmlBudget.ID, err)
+	}
+	r, err := newRecurrenceFromXML(&xmlBudget.Recurrence, xmlBudget.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	b := Budget{
+		ID:          xmlBudget.ID,
+		Name:        xmlBudget.Name,
+		Description: xmlBudget.Description,
+		NumPeriods:  numPeriods,
+		Recurrence:  *r,
+		Amounts:     map[*Account]map[int]numeric.Numeric{},
+	}
+
+	// amounts: frames with the account ID as key (other slots are ignored)
+	for _, slot := range newSlotsFromXML(xmlBudget.Slots) {
+		account := accounts.Map[slot.Key]
+		if account == nil || slot.Type != "frame" {
+			continue
+		}
+		amounts := map[int]numeric.Numeric{}
+		for _, s := range slot.Slots {
+			period, err := strconv.Atoi(s.Key)
+			if err == nil && (period < 0 || period >= numPeriods) {
+				err = fmt.Errorf("Period out of range: %d", period)
+			}
+			if err != nil {
+				return nil, formatError("Budget", "Period", xmlBudget.ID, err)
+			}
+			v, err := numeric.FromString(s.Value)
+			if err != nil {
+				return nil, formatError("Budget", "Amount", xmlBudget.ID, err)
+			}
+			amounts[period] = v
+		}
+		b.Amounts[account] = amounts
+	}
+	return &b, nil
+}
+
+func newBudgetsFromXML(xmlBudgetList []gncxml.Budget, accounts *Accounts, errs *errorCollector) (Budgets, error) {
+	budgets := Budgets{}
+	for j := range xmlBudgetList {
+		b, err := newBudgetFromXML(&xmlBudgetList[j], accounts)
+		if err != nil {
+			if err = errs.add(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		budgets = append(budgets, b)
+	}
+	sort.Sort(byBudgetName(budgets))
+	return budgets, nil
+}
+
+// used to sort Budgets
+type byBudgetName []*Budget
+
+func (a byBudgetName) Len() int           { return len(a) }
+func (a byBudgetName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byBudgetName) Less(i, j int) bool { return strings.Compare(a[i].Name, a[j].Name) < 0 }
+
+// ByName returns the budget with the given name, or nil if not found.
+func (budgets Budgets) ByName(name string) *Budget {
+	for _, b := range budgets {
+		if b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// Period returns the n-th period (from 0) of the budget. The period of a
+// recurrence without a next date (e.g. once) is open-ended.
+func (b *Budget) Period(n int) BudgetPeriod {
+	start, _ := b.Recurrence.nth(n)
+	end, ok := b.Recurrence.nth(n + 1)
+	if !ok {
+		end = time.Time{}
+	}
+	return BudgetPeriod{Start: start, End: end}
+}
+
+// Amount returns the budgeted amount of the account (without its
+// sub-accounts) in the n-th period. The second result is false if the
+// account has no amount in the period.
+func (b *Budget) Amount(a *Account, n int) (numeric.Numeric, bool) {
+	v, ok := b.Amounts[a][n]
+	return v, ok
+}
+
+// Report returns the budget vs actual report of the accounts of the book.
+// The actual amounts are the sums of the split quantities of each period, in
+// the natural sign of the account. Each row includes the amounts of the
+// sub-accounts. Rows without budget and actual amounts are omitted.
+func (b *Budget) Report(accounts *Accounts) *BudgetReport {
+	r := &BudgetReport{Budget: b}
+	for n := 0; n < b.NumPeriods; n++ {
+		r.Periods = append(r.Periods, b.Period(n))
+	}
+	if accounts.Root != nil {
+		for _, child := range accounts.Root.Children {
+			b.auxReport(r, child, 1)
+		}
+	}
+	return r
+}
+
+// auxReport is a Report auxiliary function: it appends the rows of the
+// account and of its sub-accounts and returns the row of the account
+func (b *Budget) auxReport(r *BudgetReport, a *Account, level int) *BudgetRow {
+	row := &BudgetRow{
+		Account: a,
+		Level:   level,
+		Budget:  make([]numeric.Numeric, len(r.Periods)),
+		Actual:  make([]numeric.Numeric, len(r.Periods)),
+	}
+	used := false
+
+	// own amounts
+	for n, v := range b.Amounts[a] {
+		row.Budget[n].AddEqual(&v)
+		used = true
+	}
+	for _, at := range a.AccountTransactionList {
+		d := day(at.Transaction.DatePosted)
+		for n, p := range r.Periods {
+			if p.Contains(d) {
+				v := at.Split.Quantity
+				if a.Type.InvertValues() {
+					v.NegEqual()
+				}
+				row.Actual[n].AddEqual(&v)
+				used = true
+				break
+			}
+		}
+	}
+
+	// reserve the position of the row before the sub-accounts
+	pos := len(r.Rows)
+	r.Rows = append(r.Rows, row)
+
+	for _, child := range a.Children {
+		c := b.auxReport(r, child, level+1)
+		if c == nil {
+			continue
+		}
+		used = true
+		// the amounts of the child are in its natural sign
+		invert := child.Type.InvertValues() != a.Type.InvertValues()
+		for n := range r.Periods {
+			budget, actual := c.Budget[n], c.Actual[n]
+			if invert {
+				budget.NegEqual()
+				actual.NegEqual()
+			}
+			row.Budget[n].AddEqual(&budget)
+			row.Actual[n].AddEqual(&actual)
+		}
+	}
+
+	if !used {
+		r.Rows = append(r.Rows[:pos], r.Rows[pos+1:]...)
+		return nil
+	}
+	return row
+}
+
+// Amounts returns the budget and actual amounts of the n-th period,
+// or of all the periods if n is negative.
+func (row *BudgetRow) Amounts(n int) (budget, actual numeric.Numeric) {
+	if n >= 0 {
+		return row.Budget[n], row.Actual[n]
+	}
+	for j := range row.Budget {
+		budget.AddEqual(&row.Budget[j])
+		actual.AddEqual(&row.Actual[j])
+	}
+	return
+}
+
+// Variance returns budget minus actual amount of the n-th period,
+// or of all the periods if n is negative.
+func (row *BudgetRow) Variance(n int) numeric.Numeric {
+	budget, actual := row.Amounts(n)
+	return numeric.Sub(&budget, &actual)
+}
+
+// PercentUsed returns the actual amount as a percentage of the budget of
+// the n-th period, or of all the periods if n is negative.
+// The second result is false if the budget is zero.
+func (row *BudgetRow) PercentUsed(n int) (float64, bool) {
+	budget, actual := row.Amounts(n)
+	if budget.Sign() == 0 {
+		return 0, false
+	}
+	return actual.Float64() / budget.Float64() * 100, true
+}
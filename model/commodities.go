@@ -0,0 +1,87 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// Commodities type: map of commodities by ID
+type Commodities map[string]*Commodity
+
+// Commodity type
+type Commodity struct {
+	Space    string
+	ID       string
+	Name     string
+	Fraction int
+}
+
+// defaultFraction is the fraction of commodities without an explicit one
+// (e.g. ISO4217 currencies).
+const defaultFraction = 100
+
+// IsCurrency returns true if the commodity is an ISO4217 currency.
+func (c *Commodity) IsCurrency() bool {
+	return c.Space == "ISO4217" || c.Space == "CURRENCY"
+}
+
+// Precision returns the number of decimal digits of the smallest unit
+// of the commodity (e.g. 2 for fraction 100).
+func (c *Commodity) Precision() int {
+	prec := 0
+	for f := c.Fraction; f > 1; f /= 10 {
+		prec++
+	}
+	return prec
+}
+
+func newCommoditiesFromXML(xmlCommodityList []gncxml.Commodity, errs *errorCollector) (Commodities, error) {
+	commodities := Commodities{}
+
+	for _, xmlCommodity := range xmlCommodityList {
+		fraction := defaultFraction
+		if len(xmlCommodity.Fraction) > 0 {
+			f, err := strconv.Atoi(xmlCommodity.Fraction)
+			if err == nil && f <= 0 {
+				err = errors.New("Fraction must be positive")
+			}
+			if err != nil {
+				if err = errs.add(formatError("Commodity", "Fraction", xmlCommodity.ID, err)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			fraction = f
+		}
+		commodities[xmlCommodity.ID] = &Commodity{
+			Space:    xmlCommodity.Space,
+			ID:       xmlCommodity.ID,
+			Name:     xmlCommodity.Name,
+			Fraction: fraction,
+		}
+	}
+
+	return commodities, nil
+}
+
+// Get returns the commodity with the given ID.
+// Unknown commodities are assumed to be currencies with the default fraction.
+func (commodities Commodities) Get(id string) *Commodity {
+	if c, ok := commodities[id]; ok {
+		return c
+	}
+	return &Commodity{Space: "ISO4217", ID: id, Fraction: defaultFraction}
+}
+
+// IDs returns the sorted IDs of the commodities.
+func (commodities Commodities) IDs() []string {
+	ids := make([]string, 0, len(commodities))
+	for id := range commodities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
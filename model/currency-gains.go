@@ -0,0 +1,193 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// CurrencyGain type: the gain realized disposing of an amount of a
+// foreign currency (exchanged or spent) in a transaction
+type CurrencyGain struct {
+	Currency    string // the foreign currency
+	Transaction *Transaction
+	Quantity    numeric.Numeric // amount of the foreign currency disposed of
+	Proceeds    numeric.Numeric // in the report currency, at the rate of the transaction
+	Basis       numeric.Numeric // in the report currency, at the average cost
+	Gain        numeric.Numeric
+}
+
+// CurrencyHolding type: the amount of a foreign currency held, with its
+// average cost
+type CurrencyHolding struct {
+	Currency string
+	Quantity numeric.Numeric
+	Basis    numeric.Numeric // in the report currency
+}
+
+// holdingTypes are the types of the accounts holding an amount of a
+// currency: the incomes and expenses in a foreign currency are not
+// holdings, but the counterpart of the acquisitions and disposals.
+var holdingTypes = map[string]bool{
+	"ASSET":      true,
+	"BANK":       true,
+	"CASH":       true,
+	"RECEIVABLE": true,
+	"LIABILITY":  true,
+	"CREDIT":     true,
+	"PAYABLE":    true,
+}
+
+// CurrencyGainsReport type: the currency gains and losses realized in a
+// period, in the report currency
+type CurrencyGainsReport struct {
+	From     time.Time
+	To       time.Time
+	Currency string
+	Realized []*CurrencyGain    // from From to To, sorted by date
+	Holdings []*CurrencyHolding // at To, sorted by currency
+	Warnings []string
+}
+
+// CurrencyGains computes the gains and losses realized from the day from
+// to the day to (zero from means since the beginning) on the foreign
+// currencies, i.e. the currencies other than currency (empty for the book
+// currency).
+//
+// The splits of the accounts in a foreign currency are netted by
+// transaction, so that the transfers between them don't realize gains;
+// the TRADING accounts are ignored. A net inflow is an acquisition at the
+// value of the transaction, a net outflow a disposal matched with the
+// average cost of the currency held. The values of the transactions in a
+// third currency are converted with the PriceDB of the book.
+func (book *Book) CurrencyGains(from, to time.Time, currency string) *CurrencyGainsReport {
+	if currency == "" {
+		currency = book.Currency()
+	}
+	r := &CurrencyGainsReport{From: from, To: to, Currency: currency}
+	pools := map[string]*position{}
+	db := book.PriceDB()
+
+	for _, t := range book.Transactions {
+		d := day(t.DatePosted)
+		if d.After(to) {
+			break
+		}
+
+		// net quantity and value of each foreign currency
+		quantities := map[string]numeric.Numeric{}
+		values := map[string]numeric.Numeric{}
+		for _, s := range t.Splits {
+			c := s.Account.Currency
+			if !holdingTypes[s.Account.Type.Code()] || c == currency || !book.isCurrency(c) {
+				continue
+			}
+			q, v := quantities[c], values[c]
+			q.AddEqual(&s.Quantity)
+			v.AddEqual(&s.Value)
+			quantities[c], values[c] = q, v
+		}
+		if len(quantities) == 0 {
+			continue
+		}
+		end := day(t.DatePosted).AddDate(0, 0, 1).Add(-time.Nanosecond)
+		rate, _ := db.At(t.Currency, currency, end, PriceBefore)
+		if rate == nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s: no price of %s in %s on %s",
+				t.Description, t.Currency, currency, t.DatePosted.Format("2006-01-02")))
+			continue
+		}
+
+		for _, c := range sortedCommodities(quantities) {
+			q := quantities[c]
+			tv := values[c]
+			v, err := numeric.Mul(&tv, &rate.Value)
+			if err != nil || q.Sign() == 0 {
+				continue
+			}
+			p := pools[c]
+			if p == nil {
+				p = &position{currency: c, acquired: t.DatePosted}
+				pools[c] = p
+			}
+			if q.Sign() > 0 {
+				p.quantity.AddEqual(&q)
+				p.cost.AddEqual(&v)
+				continue
+			}
+
+			g := &CurrencyGain{
+				Currency:    c,
+				Transaction: t,
+				Quantity:    numeric.Neg(&q),
+				Proceeds:    numeric.Neg(&v),
+			}
+			m := g.Quantity
+			if numeric.Cmp(&m, &p.quantity) > 0 {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s: disposal of %s %s on %s exceeds the amount held",
+					t.Description, g.Quantity.DecimalString(2), c, t.DatePosted.Format("2006-01-02")))
+				m = p.quantity
+			}
+			if m.Sign() > 0 {
+				if g.Basis, err = p.take(&m); err != nil {
+					continue
+				}
+			}
+			if excess := numeric.Sub(&g.Quantity, &m); excess.Sign() > 0 {
+				// no cost known: the excess realizes no gain
+				x, err := numeric.Mul(&g.Proceeds, &excess)
+				if err != nil {
+					continue
+				}
+				part, err := numeric.Quo(&x, &g.Quantity)
+				if err != nil {
+					continue
+				}
+				g.Basis.AddEqual(&part)
+			}
+			g.Gain = numeric.Sub(&g.Proceeds, &g.Basis)
+			if from.IsZero() || !d.Before(from) {
+				r.Realized = append(r.Realized, g)
+			}
+		}
+	}
+
+	currencies := make([]string, 0, len(pools))
+	for c := range pools {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	for _, c := range currencies {
+		if p := pools[c]; p.quantity.Sign() != 0 {
+			r.Holdings = append(r.Holdings, &CurrencyHolding{Currency: c, Quantity: p.quantity, Basis: p.cost})
+		}
+	}
+	return r
+}
+
+// Total returns the sum of the realized gains.
+func (r *CurrencyGainsReport) Total() numeric.Numeric {
+	var total numeric.Numeric
+	for _, g := range r.Realized {
+		total.AddEqual(&g.Gain)
+	}
+	return total
+}
+
+// isCurrency returns true if the commodity is a currency of the book.
+func (book *Book) isCurrency(id string) bool {
+	c := book.Commodities[id]
+	return c != nil && c.IsCurrency()
+}
+
+// sortedCommodities returns the sorted keys of the map
+func sortedCommodities(m map[string]numeric.Numeric) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// num returns the numeric of the decimal string
+func num(s string) numeric.Numeric {
+	n, err := numeric.FromDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// testAccount returns a new account of the type in the currency
+func testAccount(name, typ, currency string) *Account {
+	t := AccountTypes[typ]
+	return &Account{ID: name, Name: name, Type: &t, Currency: currency}
+}
+
+// testSplit returns a new split of the value in the transaction currency
+// and of the quantity in the account commodity
+func testSplit(a *Account, value, quantity string) *Split {
+	return &Split{ID: a.Name + value, Account: a, Value: num(value), Quantity: num(quantity)}
+}
+
+// testTransaction returns a new transaction posted at the date
+func testTransaction(date, currency string, splits ...*Split) *Transaction {
+	return &Transaction{ID: date, Currency: currency, DatePosted: ymd(date), Description: date, Splits: splits}
+}
+
+func TestCurrencyGains(t *testing.T) {
+	root := testAccount("Root", "ROOT", "EUR")
+	eur := testAccount("EUR Bank", "BANK", "EUR")
+	usd := testAccount("USD Bank", "BANK", "USD")
+	cash := testAccount("USD Cash", "CASH", "USD")
+	salary := testAccount("Salary", "INCOME", "USD")
+	travel := testAccount("Travel", "EXPENSE", "USD")
+	dinner := testAccount("Dinner", "EXPENSE", "EUR")
+
+	// 1000 USD of salary at 0.9, worth 900 EUR
+	income := testTransaction("2015-01-15", "USD", testSplit(usd, "1000", "1000"), testSplit(salary, "-1000", "-1000"))
+
+	tests := []struct {
+		name         string
+		transactions Transactions
+		realized     string // currency, quantity, proceeds, basis and gain of each gain
+		holdings     string // currency, quantity and basis of each holding
+		warnings     int
+	}{
+		{"income acquisition",
+			Transactions{income},
+			"", "USD 1000.00 900.00", 0},
+		{"exchange",
+			Transactions{income,
+				testTransaction("2015-06-15", "EUR", testSplit(usd, "-400", "-500"), testSplit(eur, "400", "400"))},
+			"USD 500.00 400.00 450.00 -50.00", "USD 500.00 450.00", 0},
+		{"spending in the foreign currency",
+			Transactions{income,
+				testTransaction("2015-06-15", "USD", testSplit(usd, "-200", "-200"), testSplit(travel, "200", "200"))},
+			"USD 200.00 160.00 180.00 -20.00", "USD 800.00 720.00", 0},
+		{"spending in the report currency",
+			Transactions{income,
+				testTransaction("2015-06-15", "EUR", testSplit(usd, "-160", "-200"), testSplit(dinner, "160", "160"))},
+			"USD 200.00 160.00 180.00 -20.00", "USD 800.00 720.00", 0},
+		{"transfer between holdings",
+			Transactions{income,
+				testTransaction("2015-06-15", "USD", testSplit(usd, "-300", "-300"), testSplit(cash, "300", "300"))},
+			"", "USD 1000.00 900.00", 0},
+		{"acquisition with the report currency",
+			Transactions{
+				testTransaction("2015-06-15", "EUR", testSplit(eur, "-400", "-400"), testSplit(usd, "400", "500"))},
+			"", "USD 500.00 400.00", 0},
+		{"overdraw",
+			Transactions{
+				testTransaction("2015-06-15", "EUR", testSplit(usd, "-400", "-500"), testSplit(eur, "400", "400"))},
+			"USD 500.00 400.00 400.00 0.00", "", 1},
+		{"partial overdraw",
+			Transactions{
+				testTransaction("2015-01-15", "USD", testSplit(usd, "100", "100"), testSplit(salary, "-100", "-100")),
+				testTransaction("2015-06-15", "EUR", testSplit(usd, "-240", "-300"), testSplit(eur, "240", "240"))},
+			"USD 300.00 240.00 250.00 -10.00", "", 1},
+	}
+
+	for _, tt := range tests {
+		book := &Book{
+			Commodities: Commodities{
+				"EUR": &Commodity{Space: "ISO4217", ID: "EUR"},
+				"USD": &Commodity{Space: "ISO4217", ID: "USD"},
+			},
+			Prices: Prices{
+				&Price{Commodity: "USD", Currency: "EUR", Time: ymd("2014-12-01"), Value: num("0.9")},
+				&Price{Commodity: "USD", Currency: "EUR", Time: ymd("2015-05-01"), Value: num("0.8")},
+			},
+			Accounts:     &Accounts{Root: root},
+			Transactions: tt.transactions,
+		}
+		r := book.CurrencyGains(ymd("2015-01-01"), ymd("2015-12-31"), "")
+
+		var realized, holdings []string
+		for _, g := range r.Realized {
+			realized = append(realized, fmt.Sprintf("%s %s %s %s %s", g.Currency, g.Quantity.DecimalString(2),
+				g.Proceeds.DecimalString(2), g.Basis.DecimalString(2), g.Gain.DecimalString(2)))
+		}
+		for _, h := range r.Holdings {
+			holdings = append(holdings, fmt.Sprintf("%s %s %s", h.Currency, h.Quantity.DecimalString(2), h.Basis.DecimalString(2)))
+		}
+		if got := strings.Join(realized, "; "); got != tt.realized {
+			t.Errorf("%s: realized %q, want %q", tt.name, got, tt.realized)
+		}
+		if got := strings.Join(holdings, "; "); got != tt.holdings {
+			t.Errorf("%s: holdings %q, want %q", tt.name, got, tt.holdings)
+		}
+		if len(r.Warnings) != tt.warnings {
+			t.Errorf("%s: warnings %q, want %d", tt.name, r.Warnings, tt.warnings)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// BookDump is the serializable representation of a Book.
+// The accounts are referenced by ID and full name instead of by pointer.
+type BookDump struct {
+	Commodities  []CommodityDump   `json:"commodities"`
+	Prices       []PriceDump       `json:"prices"`
+	Accounts     *AccountDump      `json:"accounts"`
+	Transactions []TransactionDump `json:"transactions"`
+}
+
+// CommodityDump type
+type CommodityDump struct {
+	Space    string `json:"space"`
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Fraction int    `json:"fraction"`
+}
+
+// PriceDump type
+type PriceDump struct {
+	ID        string `json:"id"`
+	Commodity string `json:"commodity"`
+	Currency  string `json:"currency"`
+	Time      string `json:"time"`
+	Source    string `json:"source,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Value     string `json:"value"`
+}
+
+// AccountDump type
+type AccountDump struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	FullName    string         `json:"full_name"`
+	Type        string         `json:"type"`
+	Description string         `json:"description,omitempty"`
+	Currency    string         `json:"currency,omitempty"`
+	Placeholder bool           `json:"placeholder,omitempty"`
+	Hidden      bool           `json:"hidden,omitempty"`
+	Balance     string         `json:"balance"`
+	Children    []*AccountDump `json:"children,omitempty"`
+}
+
+// TransactionDump type
+type TransactionDump struct {
+	ID          string      `json:"id"`
+	Currency    string      `json:"currency"`
+	DatePosted  string      `json:"date_posted"`
+	DateEntered string      `json:"date_entered"`
+	Description string      `json:"description"`
+	Splits      []SplitDump `json:"splits"`
+}
+
+// SplitDump type
+type SplitDump struct {
+	ID              string `json:"id"`
+	AccountID       string `json:"account_id"`
+	Account         string `json:"account"`
+	Value           string `json:"value"`
+	Quantity        string `json:"quantity"`
+	Memo            string `json:"memo,omitempty"`
+	ReconciledState string `json:"reconciled_state"`
+	ReconcileDate   string `json:"reconcile_date,omitempty"`
+}
+
+// dumpTime formats a time in RFC3339 format. The zero time is the empty string.
+func dumpTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// dumpNumeric formats a numeric in exact decimal form, or in the
+// "num/den" form if it has no exact decimal form (e.g. 1/3).
+func dumpNumeric(n numeric.Numeric) string {
+	s := n.DecimalString(0)
+	if x, err := numeric.FromDecimal(s); err != nil || numeric.Cmp(&x, &n) != 0 {
+		return n.FractionString()
+	}
+	return s
+}
+
+func dumpAccount(a *Account) *AccountDump {
+	d := &AccountDump{
+		ID:          a.ID,
+		Name:        a.Name,
+		FullName:    a.FullName(),
+		Type:        a.Type.Code(),
+		Description: a.Description,
+		Currency:    a.Currency,
+		Placeholder: a.Placeholder(),
+		Hidden:      a.Hidden(),
+		Balance:     dumpNumeric(a.Balance()),
+	}
+	for _, child := range a.Children {
+		d.Children = append(d.Children, dumpAccount(child))
+	}
+	return d
+}
+
+// Dump returns the serializable representation of the book.
+func (book *Book) Dump() *BookDump {
+	d := &BookDump{
+		Commodities:  []CommodityDump{},
+		Prices:       []PriceDump{},
+		Transactions: []TransactionDump{},
+	}
+
+	// commodities, sorted by ID
+	for _, id := range book.Commodities.IDs() {
+		c := book.Commodities[id]
+		d.Commodities = append(d.Commodities, CommodityDump{
+			Space:    c.Space,
+			ID:       c.ID,
+			Name:     c.Name,
+			Fraction: c.Fraction,
+		})
+	}
+
+	// prices
+	for _, p := range book.Prices {
+		d.Prices = append(d.Prices, PriceDump{
+			ID:        p.ID,
+			Commodity: p.Commodity,
+			Currency:  p.Currency,
+			Time:      dumpTime(p.Time),
+			Source:    p.Source,
+			Type:      p.Type,
+			Value:     dumpNumeric(p.Value),
+		})
+	}
+
+	// account tree
+	if book.Accounts != nil && book.Accounts.Root != nil {
+		d.Accounts = dumpAccount(book.Accounts.Root)
+	}
+
+	// transactions
+	for _, t := range book.Transactions {
+		td := TransactionDump{
+			ID:          t.ID,
+			Currency:    t.Currency,
+			DatePosted:  dumpTime(t.DatePosted),
+			DateEntered: dumpTime(t.DateEntered),
+			Description: t.Description,
+			Splits:      make([]SplitDump, 0, len(t.Splits)),
+		}
+		for _, s := range t.Splits {
+			td.Splits = append(td.Splits, SplitDump{
+				ID:              s.ID,
+				AccountID:       s.Account.ID,
+				Account:         s.Account.FullName(),
+				Value:           dumpNumeric(s.Value),
+				Quantity:        dumpNumeric(s.Quantity),
+				Memo:            s.Memo,
+				ReconciledState: s.ReconciledState,
+				ReconcileDate:   dumpTime(s.ReconcileDate),
+			})
+		}
+		d.Transactions = append(d.Transactions, td)
+	}
+
+	return d
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (book *Book) MarshalJSON() ([]byte, error) {
+	return json.Marshal(book.Dump())
+}
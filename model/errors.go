@@ -0,0 +1,106 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LoadError type: an invalid record found loading a book
+type LoadError struct {
+	Object string // kind of the record: Account, Transaction, Split, ...
+	ID     string
+	Field  string // empty if the error doesn't concern a single field
+	Err    error
+}
+
+func (e *LoadError) Error() string {
+	if e.Field == "" {
+		if _, ok := e.Err.(*LoadError); ok {
+			return fmt.Sprintf("Invalid %s (ID=%s): %s", e.Object, e.ID, e.Err.Error())
+		}
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("Invalid %s in %s (ID=%s): %s", e.Field, e.Object, e.ID, e.Err.Error())
+}
+
+// Unwrap returns the cause of the error.
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+func formatError(object, field, id string, err error) error {
+	return &LoadError{Object: object, ID: id, Field: field, Err: err}
+}
+
+// causeError returns the error of a record invalid because of the error
+// of one of its parts (e.g. a transaction with an invalid split)
+func causeError(object, id string, err error) error {
+	return &LoadError{Object: object, ID: id, Err: err}
+}
+
+// recordError returns the error of a record, without a specific field
+func recordError(object, id string, format string, args ...interface{}) error {
+	return &LoadError{Object: object, ID: id, Err: fmt.Errorf(format, args...)}
+}
+
+// LoadErrors type: the errors found loading a book in lenient mode.
+// The records with errors are skipped.
+type LoadErrors []*LoadError
+
+func (list LoadErrors) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	}
+	msgs := make([]string, len(list))
+	for j, e := range list {
+		msgs[j] = e.Error()
+	}
+	return fmt.Sprintf("%d errors loading the book:\n%s", len(list), strings.Join(msgs, "\n"))
+}
+
+// errorCollector type: handles the errors of the records in strict and
+// lenient mode.
+type errorCollector struct {
+	lenient bool
+	list    LoadErrors
+}
+
+// ErrorCollector type: handles in strict or lenient mode the errors of the
+// records of a book, including the ones loaded by other packages (e.g.
+// the business data).
+type ErrorCollector struct {
+	errorCollector
+}
+
+// NewErrorCollector returns an ErrorCollector in lenient or strict mode.
+func NewErrorCollector(lenient bool) *ErrorCollector {
+	return &ErrorCollector{errorCollector{lenient: lenient}}
+}
+
+// Add exports add to the packages loading their records with the book.
+func (c *ErrorCollector) Add(err error) error {
+	return c.add(err)
+}
+
+// Errors returns the errors collected in lenient mode.
+func (c *ErrorCollector) Errors() LoadErrors {
+	return c.list
+}
+
+// add handles the error of a record. In strict mode it returns the error,
+// that must stop the load. In lenient mode it collects the error and
+// returns nil: the caller skips the record.
+func (c *errorCollector) add(err error) error {
+	if !c.lenient {
+		return err
+	}
+	le, ok := err.(*LoadError)
+	if !ok {
+		le = &LoadError{Err: err}
+	}
+	c.list = append(c.list, le)
+	return nil
+}
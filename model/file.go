@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// File type: the books of a GnuCash file
+type File struct {
+	Books []*Book
+}
+
+// NewFile loads all the books of the file in strict mode:
+// the first invalid record is an error.
+func NewFile(gnc *gncxml.Gnc) (*File, error) {
+	return newFile(gnc, &errorCollector{})
+}
+
+// NewFileLenient loads all the books of the file skipping the invalid
+// records, like NewBookLenient.
+func NewFileLenient(gnc *gncxml.Gnc) (*File, error) {
+	errs := &errorCollector{lenient: true}
+	f, err := newFile(gnc, errs)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs.list) > 0 {
+		return f, errs.list
+	}
+	return f, nil
+}
+
+// LoadFile loads all the books of the file handling the errors of the
+// records with errs, so that the caller can go on loading other data of
+// the books (e.g. the business data) with the same collector.
+func LoadFile(gnc *gncxml.Gnc, errs *ErrorCollector) (*File, error) {
+	return newFile(gnc, &errs.errorCollector)
+}
+
+func newFile(gnc *gncxml.Gnc, errs *errorCollector) (*File, error) {
+	if gnc == nil {
+		return nil, errors.New("GNC must be not nil")
+	}
+	if len(gnc.Books) == 0 {
+		return nil, errors.New("BOOK not found")
+	}
+	f := &File{}
+	for j := range gnc.Books {
+		book, err := newBookFromXML(&gnc.Books[j], errs)
+		if err != nil {
+			return nil, err
+		}
+		f.Books = append(f.Books, book)
+	}
+	return f, nil
+}
+
+// Book returns the book with the given ID, or nil if not found.
+func (f *File) Book(id string) *Book {
+	for _, book := range f.Books {
+		if book.ID == id {
+			return book
+		}
+	}
+	return nil
+}
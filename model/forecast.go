@@ -0,0 +1,134 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// ForecastPoint type: the projected balance of an account at the end of a day
+type ForecastPoint struct {
+	Date    time.Time
+	Change  numeric.Numeric // sum of the splits of the day
+	Balance numeric.Numeric
+}
+
+// AccountForecast type: the projected daily balances of an account
+type AccountForecast struct {
+	Account      *Account
+	StartBalance numeric.Numeric // balance before the first day
+	Points       []ForecastPoint // one for each day of the forecast
+	MinBalance   numeric.Numeric
+	MinDate      time.Time
+	NegativeDate time.Time // first day with a negative balance of a BANK account, zero if none
+}
+
+// Forecast type: the projection of the balances of some accounts
+type Forecast struct {
+	From     time.Time
+	To       time.Time
+	Accounts []*AccountForecast
+	Warnings []string // template splits that could not be applied
+}
+
+// day returns the local midnight of the calendar day of t in the time
+// zone of t (i.e. the day entered in GnuCash)
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Forecast projects day by day, from the day from to the day to, the
+// balances of the accounts applying both the splits already in the book
+// and the next occurrences of the enabled scheduled transactions.
+// The occurrences before from not yet created are ignored.
+func (book *Book) Forecast(from, to time.Time, accounts []*Account) *Forecast {
+	from, to = day(from), day(to)
+	f := &Forecast{From: from, To: to}
+
+	// changes by account and day
+	changes := map[*Account]map[time.Time]*numeric.Numeric{}
+	add := func(a *Account, d time.Time, v *numeric.Numeric) {
+		m := changes[a]
+		if m == nil {
+			m = map[time.Time]*numeric.Numeric{}
+			changes[a] = m
+		}
+		if m[d] == nil {
+			m[d] = &numeric.Numeric{}
+		}
+		m[d].AddEqual(v)
+	}
+
+	selected := map[*Account]bool{}
+	for _, a := range accounts {
+		selected[a] = true
+	}
+
+	// splits already in the book
+	start := map[*Account]*numeric.Numeric{}
+	for _, a := range accounts {
+		start[a] = &numeric.Numeric{}
+		for _, at := range a.AccountTransactionList {
+			d := day(at.Transaction.DatePosted)
+			v := at.Split.Quantity
+			switch {
+			case d.Before(from):
+				start[a].AddEqual(&v)
+			case !d.After(to):
+				add(a, d, &v)
+			}
+		}
+	}
+
+	// scheduled transactions
+	for _, sx := range book.ScheduledTransactions {
+		dates := sx.NextOccurrences(from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+		if len(dates) == 0 {
+			continue
+		}
+		for _, tt := range sx.Templates {
+			for _, ts := range tt.Splits {
+				if ts.Account == nil || !selected[ts.Account] {
+					continue
+				}
+				v, err := ts.Value()
+				if err != nil {
+					f.Warnings = append(f.Warnings, fmt.Sprintf("%s: %s: %s", sx.Name, ts.Account.FullName(), err))
+					continue
+				}
+				for _, d := range dates {
+					add(ts.Account, day(d), &v)
+				}
+			}
+		}
+	}
+
+	// daily balances
+	for _, a := range accounts {
+		af := &AccountForecast{Account: a}
+		af.StartBalance.Set(start[a])
+		balance := *start[a]
+		af.MinBalance.Set(&balance)
+		af.MinDate = from
+		bank := a.Type.Code() == "BANK"
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			p := ForecastPoint{Date: d}
+			if c := changes[a][d]; c != nil {
+				p.Change.Set(c)
+				balance.AddEqual(c)
+			}
+			p.Balance.Set(&balance)
+			if numeric.Cmp(&balance, &af.MinBalance) < 0 {
+				af.MinBalance.Set(&balance)
+				af.MinDate = d
+			}
+			if bank && balance.Sign() < 0 && af.NegativeDate.IsZero() {
+				af.NegativeDate = d
+			}
+			af.Points = append(af.Points, p)
+		}
+		f.Accounts = append(f.Accounts, af)
+	}
+	return f
+}
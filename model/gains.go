@@ -0,0 +1,292 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Cost basis methods of the sales without lot
+const (
+	MethodFIFO    = "fifo"
+	MethodLIFO    = "lifo"
+	MethodAverage = "average"
+)
+
+// investmentTypes are the types of the accounts holding a commodity
+var investmentTypes = map[string]bool{"STOCK": true, "MUTUAL": true}
+
+// IsInvestment returns true if the account is a STOCK or MUTUAL account.
+func (a *Account) IsInvestment() bool {
+	return investmentTypes[a.Type.Code()]
+}
+
+// RealizedGain type: the gain of the sale of (part of) a position
+type RealizedGain struct {
+	Account  *Account
+	Lot      *Lot                // nil if the sale is not in a lot
+	Sale     *AccountTransaction // the selling split
+	Acquired time.Time
+	Quantity numeric.Numeric
+	Proceeds numeric.Numeric
+	Basis    numeric.Numeric
+	Gain     numeric.Numeric
+	LongTerm bool // held for more than one year
+}
+
+// UnrealizedGain type: the gain of a position still open
+type UnrealizedGain struct {
+	Account  *Account
+	Lot      *Lot // nil if the position is not in a lot
+	Currency string
+	Acquired time.Time
+	Quantity numeric.Numeric
+	Basis    numeric.Numeric
+	Price    numeric.Numeric
+	Value    numeric.Numeric
+	Gain     numeric.Numeric
+	LongTerm bool   // held for more than one year
+	Source   string // source of the price (PriceSourceTransaction if implied by a split)
+}
+
+// GainsReport type: the capital gains of the investment accounts
+type GainsReport struct {
+	From       time.Time
+	To         time.Time
+	Method     string
+	Realized   []*RealizedGain   // sales from From to To
+	Unrealized []*UnrealizedGain // positions open at To
+	Warnings   []string
+}
+
+// position type: an open position of an investment account
+type position struct {
+	lot      *Lot
+	currency string
+	acquired time.Time
+	quantity numeric.Numeric
+	cost     numeric.Numeric
+}
+
+// longTerm returns true if the position acquired at the date is held for
+// more than one year at the date sold
+func longTerm(acquired, sold time.Time) bool {
+	return sold.After(acquired.AddDate(1, 0, 0))
+}
+
+// take removes the quantity q (not greater than the position quantity)
+// from the position and returns its cost basis
+func (p *position) take(q *numeric.Numeric) (numeric.Numeric, error) {
+	if numeric.Cmp(q, &p.quantity) == 0 {
+		basis := p.cost
+		p.quantity, p.cost = numeric.Numeric{}, numeric.Numeric{}
+		return basis, nil
+	}
+	x, err := numeric.Mul(&p.cost, q)
+	if err != nil {
+		return x, err
+	}
+	basis, err := numeric.Quo(&x, &p.quantity)
+	if err != nil {
+		return basis, err
+	}
+	p.quantity.SubEqual(q)
+	p.cost.SubEqual(&basis)
+	return basis, nil
+}
+
+// CapitalGains computes the gains realized from the day from to the day
+// to (zero from means since the beginning) and the gains not realized at
+// the day to, of all the investment accounts.
+//
+// The sales of a lot are matched with the purchases of the same lot;
+// the other sales, and the part of a sale exceeding its lot, with the
+// purchases without lot, according to the method. With the average
+// method the purchases without lot are pooled by currency, and the pool
+// keeps the date of its first purchase.
+// The current value of the open positions is computed with the price
+// returned by PriceDB.AccountPrice.
+func (book *Book) CapitalGains(from, to time.Time, method string) (*GainsReport, error) {
+	switch method {
+	case MethodFIFO, MethodLIFO, MethodAverage:
+	default:
+		return nil, fmt.Errorf("Invalid cost basis method: %s", method)
+	}
+	r := &GainsReport{From: from, To: to, Method: method}
+
+	db := book.PriceDB()
+	var err error
+	book.Accounts.Walk(func(a *Account, level int) {
+		if err == nil && a.IsInvestment() {
+			err = r.addAccount(db, a)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// addAccount adds the gains of the account to the report
+func (r *GainsReport) addAccount(db *PriceDB, a *Account) error {
+	var positions []*position
+	pools := map[string]*position{} // by currency, with the average method
+
+	for _, at := range a.AccountTransactionList {
+		t, s := at.Transaction, at.Split
+		if day(t.DatePosted).After(r.To) {
+			break
+		}
+		q := s.Quantity
+		if q.Sign() == 0 {
+			// e.g. the splits of the gains transactions
+			continue
+		}
+
+		if q.Sign() > 0 {
+			// purchase
+			pooled := r.Method == MethodAverage && s.Lot == nil
+			if p := pools[t.Currency]; pooled && p != nil && p.quantity.Sign() > 0 {
+				p.quantity.AddEqual(&q)
+				p.cost.AddEqual(&s.Value)
+				continue
+			}
+			p := &position{
+				lot:      s.Lot,
+				currency: t.Currency,
+				acquired: t.DatePosted,
+				quantity: q,
+				cost:     s.Value,
+			}
+			positions = append(positions, p)
+			if pooled {
+				pools[t.Currency] = p
+			}
+			continue
+		}
+
+		// sale
+		sold := numeric.Neg(&q)
+		proceeds := numeric.Neg(&s.Value)
+		remaining := sold
+		for remaining.Sign() > 0 {
+			p := r.pick(positions, s.Lot)
+			if p == nil {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s: sale of %s on %s exceeds the open positions",
+					a.FullName(), remaining.DecimalString(2), t.DatePosted.Format("2006-01-02")))
+				break
+			}
+			m := p.quantity
+			if numeric.Cmp(&remaining, &m) < 0 {
+				m = remaining
+			}
+			basis, err := p.take(&m)
+			if err != nil {
+				return err
+			}
+			remaining.SubEqual(&m)
+
+			// proceeds of the quantity m
+			part := proceeds
+			if numeric.Cmp(&m, &sold) != 0 {
+				x, err := numeric.Mul(&proceeds, &m)
+				if err != nil {
+					return err
+				}
+				if part, err = numeric.Quo(&x, &sold); err != nil {
+					return err
+				}
+			}
+			if !r.From.IsZero() && day(t.DatePosted).Before(r.From) {
+				continue
+			}
+			r.Realized = append(r.Realized, &RealizedGain{
+				Account:  a,
+				Lot:      p.lot,
+				Sale:     at,
+				Acquired: p.acquired,
+				Quantity: m,
+				Proceeds: part,
+				Basis:    basis,
+				Gain:     numeric.Sub(&part, &basis),
+				LongTerm: longTerm(p.acquired, t.DatePosted),
+			})
+		}
+		positions = removeClosed(positions)
+	}
+
+	// open positions
+	for _, p := range positions {
+		u := &UnrealizedGain{
+			Account:  a,
+			Lot:      p.lot,
+			Currency: p.currency,
+			Acquired: p.acquired,
+			Quantity: p.quantity,
+			Basis:    p.cost,
+			LongTerm: longTerm(p.acquired, r.To),
+		}
+		if price := db.AccountPrice(a, p.currency, r.To); price != nil {
+			u.Price, u.Source = price.Value, price.Source
+		}
+		var err error
+		if u.Value, err = numeric.Mul(&p.quantity, &u.Price); err != nil {
+			return err
+		}
+		u.Gain = numeric.Sub(&u.Value, &u.Basis)
+		r.Unrealized = append(r.Unrealized, u)
+	}
+	return nil
+}
+
+// pick returns the position the sale takes the commodity from: the
+// oldest position of the lot, if any, else one of the positions without
+// lot according to the method. The positions of the other lots are never
+// taken.
+func (r *GainsReport) pick(positions []*position, lot *Lot) *position {
+	if lot != nil {
+		for _, p := range positions {
+			if p.lot == lot && p.quantity.Sign() > 0 {
+				return p
+			}
+		}
+	}
+	if r.Method == MethodLIFO {
+		for j := len(positions) - 1; j >= 0; j-- {
+			if p := positions[j]; p.lot == nil && p.quantity.Sign() > 0 {
+				return p
+			}
+		}
+		return nil
+	}
+	for _, p := range positions {
+		if p.lot == nil && p.quantity.Sign() > 0 {
+			return p
+		}
+	}
+	return nil
+}
+
+// removeClosed returns the positions with a positive quantity
+func removeClosed(positions []*position) []*position {
+	res := positions[:0]
+	for _, p := range positions {
+		if p.quantity.Sign() > 0 {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Totals returns the short and long term totals of the realized gains.
+func (r *GainsReport) Totals() (short, long numeric.Numeric) {
+	for _, g := range r.Realized {
+		if g.LongTerm {
+			long.AddEqual(&g.Gain)
+		} else {
+			short.AddEqual(&g.Gain)
+		}
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCapitalGains(t *testing.T) {
+	stock := testAccount("ACME", "STOCK", "ACME")
+	lotL := &Lot{ID: "L", Account: stock}
+	lotM := &Lot{ID: "M", Account: stock}
+
+	// trade returns the transaction of shares for value, in the lot if any
+	trade := func(date, shares, value string, lot *Lot) *Transaction {
+		s := testSplit(stock, value, shares)
+		s.Lot = lot
+		return testTransaction(date, "EUR", s)
+	}
+	p1 := trade("2015-01-10", "10", "1000", nil)
+	p2 := trade("2015-02-10", "10", "1200", nil)
+	sale := trade("2015-03-10", "-15", "-1800", nil)
+
+	tests := []struct {
+		name         string
+		method       string
+		transactions []*Transaction
+		realized     string // lot, quantity, proceeds, basis and gain of each gain
+		open         string // lot, quantity and basis of each open position
+		warnings     int
+	}{
+		{"fifo", MethodFIFO,
+			[]*Transaction{p1, p2, sale},
+			"- 10.00 1200.00 1000.00 200.00; - 5.00 600.00 600.00 0.00", "- 5.00 600.00", 0},
+		{"lifo", MethodLIFO,
+			[]*Transaction{p1, p2, sale},
+			"- 10.00 1200.00 1200.00 0.00; - 5.00 600.00 500.00 100.00", "- 5.00 500.00", 0},
+		{"average", MethodAverage,
+			[]*Transaction{p1, p2, sale},
+			"- 15.00 1800.00 1650.00 150.00", "- 5.00 550.00", 0},
+		{"average with a lot purchase in between", MethodAverage,
+			[]*Transaction{p1, trade("2015-01-20", "10", "2000", lotL), p2, sale},
+			"- 15.00 1800.00 1650.00 150.00", "- 5.00 550.00; L 10.00 2000.00", 0},
+		{"sale of a lot", MethodFIFO,
+			[]*Transaction{p1, trade("2015-01-20", "5", "1000", lotL), trade("2015-03-10", "-4", "-1000", lotL)},
+			"L 4.00 1000.00 800.00 200.00", "- 10.00 1000.00; L 1.00 200.00", 0},
+		{"sale exceeding its lot", MethodFIFO,
+			[]*Transaction{
+				trade("2015-01-05", "10", "3000", lotM),
+				p1,
+				trade("2015-01-20", "5", "1000", lotL),
+				trade("2015-03-10", "-8", "-1600", lotL)},
+			"L 5.00 1000.00 1000.00 0.00; - 3.00 600.00 300.00 300.00", "M 10.00 3000.00; - 7.00 700.00", 0},
+		{"sale without lot of lot positions", MethodFIFO,
+			[]*Transaction{trade("2015-01-05", "10", "3000", lotM), trade("2015-03-10", "-5", "-1000", nil)},
+			"", "M 10.00 3000.00", 1},
+		{"sale exceeding the positions", MethodLIFO,
+			[]*Transaction{p1, trade("2015-03-10", "-12", "-1200", nil)},
+			"- 10.00 1000.00 1000.00 0.00", "", 1},
+	}
+
+	for _, tt := range tests {
+		stock.AccountTransactionList = nil
+		for _, tr := range tt.transactions {
+			stock.AccountTransactionList = append(stock.AccountTransactionList, &AccountTransaction{Transaction: tr, Split: tr.Splits[0]})
+		}
+		r := &GainsReport{To: ymd("2015-12-31"), Method: tt.method}
+		if err := r.addAccount(NewPriceDB(nil, "EUR"), stock); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		lotID := func(lot *Lot) string {
+			if lot == nil {
+				return "-"
+			}
+			return lot.ID
+		}
+		var realized, open []string
+		for _, g := range r.Realized {
+			realized = append(realized, fmt.Sprintf("%s %s %s %s %s", lotID(g.Lot), g.Quantity.DecimalString(2),
+				g.Proceeds.DecimalString(2), g.Basis.DecimalString(2), g.Gain.DecimalString(2)))
+		}
+		for _, u := range r.Unrealized {
+			open = append(open, fmt.Sprintf("%s %s %s", lotID(u.Lot), u.Quantity.DecimalString(2), u.Basis.DecimalString(2)))
+		}
+		if got := strings.Join(realized, "; "); got != tt.realized {
+			t.Errorf("%s: realized %q, want %q", tt.name, got, tt.realized)
+		}
+		if got := strings.Join(open, "; "); got != tt.open {
+			t.Errorf("%s: open positions %q, want %q", tt.name, got, tt.open)
+		}
+		if len(r.Warnings) != tt.warnings {
+			t.Errorf("%s: warnings %q, want %d", tt.name, r.Warnings, tt.warnings)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// Lot type: a group of splits of an account, used to track the cost
+// basis of the commodity bought and sold
+type Lot struct {
+	ID                     string
+	Account                *Account
+	Slots                  Slots
+	AccountTransactionList []*AccountTransaction // sorted by date posted
+}
+
+func newLotsFromXML(xmlLotList []gncxml.Lot, account *Account) []*Lot {
+	if len(xmlLotList) == 0 {
+		return nil
+	}
+	lots := make([]*Lot, 0, len(xmlLotList))
+	for _, xmlLot := range xmlLotList {
+		lots = append(lots, &Lot{
+			ID:      xmlLot.ID,
+			Account: account,
+			Slots:   newSlotsFromXML(xmlLot.Slots),
+		})
+	}
+	return lots
+}
+
+// Lot returns the lot of the account with the given ID, or nil if not found.
+func (a *Account) Lot(id string) *Lot {
+	for _, lot := range a.Lots {
+		if lot.ID == id {
+			return lot
+		}
+	}
+	return nil
+}
+
+// Title returns the title of the lot.
+func (lot *Lot) Title() string {
+	return lot.Slots.Value("title")
+}
+
+// Quantity returns the quantity of the commodity still in the lot.
+func (lot *Lot) Quantity() numeric.Numeric {
+	var q numeric.Numeric
+	for _, at := range lot.AccountTransactionList {
+		q.AddEqual(&at.Split.Quantity)
+	}
+	return q
+}
+
+// Closed returns true if all the commodity of the lot was sold.
+func (lot *Lot) Closed() bool {
+	q := lot.Quantity()
+	return len(lot.AccountTransactionList) > 0 && q.Sign() == 0
+}
+
+// Basis returns the cost basis of the lot, i.e. the value of the splits
+// that bought the commodity.
+func (lot *Lot) Basis() numeric.Numeric {
+	var basis numeric.Numeric
+	for _, at := range lot.AccountTransactionList {
+		if at.Split.Quantity.Sign() > 0 {
+			basis.AddEqual(&at.Split.Value)
+		}
+	}
+	return basis
+}
+
+// Opened returns the date of the first split of the lot (zero if empty).
+func (lot *Lot) Opened() time.Time {
+	if len(lot.AccountTransactionList) == 0 {
+		return time.Time{}
+	}
+	return lot.AccountTransactionList[0].Transaction.DatePosted
+}
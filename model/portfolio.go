@@ -0,0 +1,307 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Portfolio type: the holdings of the investment accounts at the day To,
+// grouped by parent account, with their performance from the day From
+type Portfolio struct {
+	From     time.Time // zero means the beginning
+	To       time.Time
+	Groups   []*PortfolioGroup // in account tree order
+	Total    *PortfolioGroup   // all the holdings (Account is nil)
+	Warnings []string
+}
+
+// PortfolioGroup type: the holdings with the same parent account
+type PortfolioGroup struct {
+	Account  *Account
+	Holdings []*PortfolioHolding
+	Cost     numeric.Numeric
+	Value    numeric.Numeric
+	Gain     numeric.Numeric
+	performance
+}
+
+// PortfolioHolding type: the commodity held in an investment account.
+// The cost is the average cost of the shares held.
+type PortfolioHolding struct {
+	Account  *Account
+	Currency string
+	Shares   numeric.Numeric
+	Cost     numeric.Numeric
+	AvgCost  numeric.Numeric // cost of one share
+	Price    *Price          // nil if the commodity has no price
+	Value    numeric.Numeric // zero if not Valued
+	Gain     numeric.Numeric // zero if not Valued
+	performance
+}
+
+// cashFlow type: an amount invested (negative) or returned (positive)
+type cashFlow struct {
+	date   time.Time
+	amount float64
+}
+
+// performance type: the data to compute the returns of one or more holdings
+type performance struct {
+	prices   *PriceDB
+	from     time.Time
+	to       time.Time
+	flows    []cashFlow // sorted by date
+	holdings []*PortfolioHolding
+}
+
+// Portfolio returns the holdings of the investment accounts at the day to,
+// and their returns from the day from (zero means the beginning).
+// The accounts without shares and without splits in the period are omitted.
+func (book *Book) Portfolio(from, to time.Time) (*Portfolio, error) {
+	db := book.PriceDB()
+	pf := &Portfolio{
+		From:  from,
+		To:    to,
+		Total: &PortfolioGroup{performance: performance{prices: db, from: from, to: to}},
+	}
+	groups := map[*Account]*PortfolioGroup{}
+	currencies := map[string]bool{}
+
+	var err error
+	book.Accounts.Walk(func(a *Account, level int) {
+		if err != nil || !a.IsInvestment() {
+			return
+		}
+		var h *PortfolioHolding
+		if h, err = holding(db, a, from, to); err != nil || h == nil {
+			return
+		}
+		if h.Price == nil && h.Shares.Sign() != 0 {
+			pf.Warnings = append(pf.Warnings, "No price of "+a.Currency+" for "+a.FullName())
+		}
+		currencies[h.Currency] = true
+
+		g := groups[a.Parent]
+		if g == nil {
+			g = &PortfolioGroup{Account: a.Parent, performance: performance{prices: db, from: from, to: to}}
+			groups[a.Parent] = g
+			pf.Groups = append(pf.Groups, g)
+		}
+		g.add(h)
+		pf.Total.add(h)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(currencies) > 1 {
+		pf.Warnings = append(pf.Warnings, "The holdings have different currencies: the totals are not meaningful")
+	}
+	return pf, nil
+}
+
+// add adds the holding to the group. The holdings not valued are listed
+// but kept out of the totals and the returns.
+func (g *PortfolioGroup) add(h *PortfolioHolding) {
+	g.Holdings = append(g.Holdings, h)
+	if !h.Valued() {
+		return
+	}
+	g.holdings = append(g.holdings, h)
+	g.Cost.AddEqual(&h.Cost)
+	g.Value.AddEqual(&h.Value)
+	g.Gain.AddEqual(&h.Gain)
+	g.flows = append(g.flows, h.flows...)
+	sort.SliceStable(g.flows, func(i, j int) bool { return g.flows[i].date.Before(g.flows[j].date) })
+}
+
+// holding returns the holding of the investment account, or nil if the
+// account has no shares and no splits in the period
+func holding(db *PriceDB, a *Account, from, to time.Time) (*PortfolioHolding, error) {
+	h := &PortfolioHolding{
+		Account:     a,
+		performance: performance{prices: db, from: from, to: to},
+	}
+	for _, at := range a.AccountTransactionList {
+		t, s := at.Transaction, at.Split
+		d := day(t.DatePosted)
+		if d.After(to) {
+			break
+		}
+		if h.Currency == "" || s.Quantity.Sign() != 0 {
+			h.Currency = t.Currency
+		}
+
+		// average cost
+		switch s.Quantity.Sign() {
+		case 1:
+			h.Cost.AddEqual(&s.Value)
+		case -1:
+			if h.Shares.Sign() > 0 {
+				q := numeric.Neg(&s.Quantity)
+				if numeric.Cmp(&q, &h.Shares) >= 0 {
+					h.Cost = numeric.Numeric{}
+				} else {
+					x, err := numeric.Mul(&h.Cost, &q)
+					if err != nil {
+						return nil, err
+					}
+					sold, err := numeric.Quo(&x, &h.Shares)
+					if err != nil {
+						return nil, err
+					}
+					h.Cost.SubEqual(&sold)
+				}
+			}
+		}
+		h.Shares.AddEqual(&s.Quantity)
+
+		if s.Quantity.Sign() != 0 && (from.IsZero() || !d.Before(from)) {
+			// the money invested is the value of the split
+			h.flows = append(h.flows, cashFlow{date: d, amount: -s.Value.Float64()})
+		}
+	}
+	if h.Shares.Sign() == 0 && len(h.flows) == 0 {
+		return nil, nil
+	}
+	h.holdings = []*PortfolioHolding{h}
+
+	if h.Shares.Sign() != 0 {
+		var err error
+		if h.AvgCost, err = numeric.Quo(&h.Cost, &h.Shares); err != nil {
+			return nil, err
+		}
+	}
+	if h.Price = db.AccountPrice(a, h.Currency, to); h.Price != nil {
+		var err error
+		if h.Value, err = numeric.Mul(&h.Shares, &h.Price.Value); err != nil {
+			return nil, err
+		}
+	}
+	if !h.Valued() {
+		// no returns without the final value
+		h.flows, h.holdings = nil, nil
+		return h, nil
+	}
+	h.Gain = numeric.Sub(&h.Value, &h.Cost)
+	return h, nil
+}
+
+// Valued returns true if the market value of the holding is known: the
+// commodity has a price, or no shares are held.
+func (h *PortfolioHolding) Valued() bool {
+	return h.Price != nil || h.Shares.Sign() == 0
+}
+
+// valueAt returns the market value of the holdings at the end of the day
+func (p *performance) valueAt(d time.Time) float64 {
+	var v float64
+	for _, h := range p.holdings {
+		var shares numeric.Numeric
+		for _, at := range h.Account.AccountTransactionList {
+			if day(at.Transaction.DatePosted).After(d) {
+				break
+			}
+			shares.AddEqual(&at.Split.Quantity)
+		}
+		if shares.Sign() == 0 {
+			continue
+		}
+		if price := p.prices.AccountPrice(h.Account, h.Currency, d); price != nil {
+			v += shares.Float64() * price.Value.Float64()
+		}
+	}
+	return v
+}
+
+// MoneyWeightedReturn returns the annualized internal rate of return
+// (XIRR) of the period: the value at the start of the period is an
+// investment, each split a cash flow, the value at the end a return.
+// The second result is false if the rate can not be computed.
+func (p *performance) MoneyWeightedReturn() (float64, bool) {
+	var flows []cashFlow
+	if !p.from.IsZero() {
+		start := p.from.AddDate(0, 0, -1)
+		if v := p.valueAt(start); v != 0 {
+			flows = append(flows, cashFlow{date: p.from, amount: -v})
+		}
+	}
+	flows = append(flows, p.flows...)
+	flows = append(flows, cashFlow{date: p.to, amount: p.valueAt(p.to)})
+	return xirr(flows)
+}
+
+// TimeWeightedReturn returns the return of the period (not annualized)
+// chaining the returns of the sub-periods between the days with splits,
+// so that it does not depend on the amounts invested.
+// The second result is false if nothing is held in the period.
+func (p *performance) TimeWeightedReturn() (float64, bool) {
+	var prev float64
+	if !p.from.IsZero() {
+		prev = p.valueAt(p.from.AddDate(0, 0, -1))
+	}
+	factor, ok := 1.0, false
+	for j := 0; j < len(p.flows); {
+		d := p.flows[j].date
+		var invested float64
+		for ; j < len(p.flows) && p.flows[j].date.Equal(d); j++ {
+			invested -= p.flows[j].amount
+		}
+		v := p.valueAt(d)
+		if prev > 0 {
+			factor *= (v - invested) / prev
+			ok = true
+		}
+		prev = v
+	}
+	if prev > 0 {
+		factor *= p.valueAt(p.to) / prev
+		ok = true
+	}
+	if !ok {
+		return 0, false
+	}
+	return factor - 1, true
+}
+
+// xirr returns the annual rate r such that the sum of the cash flows
+// discounted at the first date is zero
+func xirr(flows []cashFlow) (float64, bool) {
+	var in, out bool
+	for _, f := range flows {
+		in = in || f.amount < 0
+		out = out || f.amount > 0
+	}
+	if len(flows) < 2 || !in || !out {
+		return 0, false
+	}
+	d0 := flows[0].date
+	npv := func(r float64) float64 {
+		var sum float64
+		for _, f := range flows {
+			years := f.date.Sub(d0).Hours() / 24 / 365
+			sum += f.amount / math.Pow(1+r, years)
+		}
+		return sum
+	}
+
+	// bisection: npv is decreasing in r when the investments come first
+	lo, hi := -0.9999, 1.0
+	for npv(hi) > 0 && hi < 1e6 {
+		hi *= 2
+	}
+	if npv(lo)*npv(hi) > 0 {
+		return 0, false
+	}
+	for k := 0; k < 200 && hi-lo > 1e-10; k++ {
+		mid := (lo + hi) / 2
+		if npv(lo)*npv(mid) <= 0 {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return (lo + hi) / 2, true
+}
@@ -0,0 +1,330 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Sources of the prices computed by the PriceDB
+const (
+	PriceSourceInverse      = "inverse"      // inverse of the price of the reversed pair
+	PriceSourceTriangulated = "triangulated" // product of the prices through the base currency
+	PriceSourceInterpolated = "interpolated" // linear interpolation of two prices
+)
+
+// Lookup modes of PriceDB.At
+const (
+	PriceBefore       = "before"       // the latest price not after the time
+	PriceAfter        = "after"        // the earliest price not before the time
+	PriceNearest      = "nearest"      // the price nearest in time
+	PriceInterpolated = "interpolated" // interpolated between the prices before and after
+)
+
+// interpolatedPrecision is the number of decimal digits of the
+// interpolated prices
+const interpolatedPrecision = 8
+
+// pricePair type: the key of the prices of a commodity in a currency
+type pricePair struct {
+	commodity string
+	currency  string
+}
+
+// PriceDB type: the prices of a book indexed by commodity and currency.
+// The prices of a pair not in the database are derived from the reversed
+// pair or, through the Base currency, from two other pairs.
+type PriceDB struct {
+	Base  string
+	pairs map[pricePair]Prices // sorted by time
+}
+
+// NewPriceDB returns the price database of the prices, using base as
+// the currency of the triangulations.
+func NewPriceDB(prices Prices, base string) *PriceDB {
+	db := &PriceDB{Base: base, pairs: map[pricePair]Prices{}}
+	for _, p := range prices {
+		key := pricePair{p.Commodity, p.Currency}
+		db.pairs[key] = append(db.pairs[key], p)
+	}
+	for _, list := range db.pairs {
+		sort.Stable(byPriceTime(list))
+	}
+	return db
+}
+
+// PriceDB returns the price database of the book, with the book currency
+// as base currency.
+func (book *Book) PriceDB() *PriceDB {
+	return NewPriceDB(book.Prices, book.Currency())
+}
+
+// Commodities returns the sorted commodities with at least a price.
+func (db *PriceDB) Commodities() []string {
+	seen := map[string]bool{}
+	for key := range db.pairs {
+		seen[key.commodity] = true
+	}
+	list := make([]string, 0, len(seen))
+	for c := range seen {
+		list = append(list, c)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Currencies returns the sorted currencies of the prices of the commodity.
+func (db *PriceDB) Currencies(commodity string) []string {
+	var list []string
+	for key := range db.pairs {
+		if key.commodity == commodity {
+			list = append(list, key.currency)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Latest returns the most recent price of the commodity in the currency,
+// or nil if not found.
+func (db *PriceDB) Latest(commodity, currency string) *Price {
+	p, _ := db.At(commodity, currency, time.Time{}, PriceBefore)
+	return p
+}
+
+// At returns the price of the commodity in the currency at the time t
+// (zero t means the most recent price) according to the lookup mode.
+// The price is taken from the pair, else from the reversed pair, else
+// triangulated through the base currency. It returns nil if no price is
+// found, and an error if the mode is invalid.
+func (db *PriceDB) At(commodity, currency string, t time.Time, mode string) (*Price, error) {
+	switch mode {
+	case PriceBefore, PriceAfter, PriceNearest, PriceInterpolated:
+	default:
+		return nil, fmt.Errorf("Invalid price lookup mode: %s", mode)
+	}
+	if commodity == currency {
+		return &Price{Commodity: commodity, Currency: currency, Time: t, Value: numeric.New(1, 1)}, nil
+	}
+	if p := db.lookup(commodity, currency, t, mode); p != nil {
+		return p, nil
+	}
+	if commodity == db.Base || currency == db.Base || db.Base == "" {
+		return nil, nil
+	}
+	p1 := db.lookup(commodity, db.Base, t, mode)
+	if p1 == nil {
+		return nil, nil
+	}
+	p2 := db.lookup(db.Base, currency, t, mode)
+	if p2 == nil {
+		return nil, nil
+	}
+	v, err := numeric.Mul(&p1.Value, &p2.Value)
+	if err != nil {
+		return nil, nil
+	}
+	p := &Price{
+		Commodity: commodity,
+		Currency:  currency,
+		Time:      p1.Time,
+		Source:    PriceSourceTriangulated,
+		Value:     v,
+	}
+	if p2.Time.Before(p.Time) {
+		p.Time = p2.Time
+	}
+	return p, nil
+}
+
+// AccountPrice returns the price of the commodity of the investment
+// account in the currency at the end of the day d: the latest price of
+// the database or, if missing, the price implied by the last split of the
+// account (with Source PriceSourceTransaction). It returns nil if no
+// price is found.
+func (db *PriceDB) AccountPrice(a *Account, currency string, d time.Time) *Price {
+	end := day(d).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	if p, _ := db.At(a.Currency, currency, end, PriceBefore); p != nil {
+		return p
+	}
+	var last *AccountTransaction
+	for _, at := range a.AccountTransactionList {
+		if day(at.Transaction.DatePosted).After(d) {
+			break
+		}
+		if at.Split.Quantity.Sign() != 0 && at.Transaction.Currency == currency {
+			last = at
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	v, err := numeric.Quo(&last.Split.Value, &last.Split.Quantity)
+	if err != nil {
+		return nil
+	}
+	return &Price{
+		Commodity: a.Currency,
+		Currency:  currency,
+		Time:      last.Transaction.DatePosted,
+		Source:    PriceSourceTransaction,
+		Value:     v,
+	}
+}
+
+// lookup returns the price of the pair or the inverse of the price of
+// the reversed pair, whichever is nearer to the time t (the most recent
+// if t is zero), according to the mode. On a tie the pair wins.
+func (db *PriceDB) lookup(commodity, currency string, t time.Time, mode string) *Price {
+	direct := find(db.pairs[pricePair{commodity, currency}], t, mode)
+	inverse := db.inverse(commodity, currency, t, mode)
+	switch {
+	case inverse == nil:
+		return direct
+	case direct == nil:
+		return inverse
+	case t.IsZero():
+		if inverse.Time.After(direct.Time) {
+			return inverse
+		}
+	case distance(inverse.Time, t) < distance(direct.Time, t):
+		return inverse
+	}
+	return direct
+}
+
+// distance returns the absolute duration between the times t1 and t2
+func distance(t1, t2 time.Time) time.Duration {
+	if d := t1.Sub(t2); d > 0 {
+		return d
+	}
+	return t2.Sub(t1)
+}
+
+// inverse returns the inverse of the price of the reversed pair at the
+// time t according to the mode
+func (db *PriceDB) inverse(commodity, currency string, t time.Time, mode string) *Price {
+	p := find(db.pairs[pricePair{currency, commodity}], t, mode)
+	if p == nil || p.Value.Sign() == 0 {
+		return nil
+	}
+	one := numeric.New(1, 1)
+	v, err := numeric.Quo(&one, &p.Value)
+	if err != nil {
+		return nil
+	}
+	return &Price{
+		ID:        p.ID,
+		Commodity: commodity,
+		Currency:  currency,
+		Time:      p.Time,
+		Source:    PriceSourceInverse,
+		Type:      p.Type,
+		Value:     v,
+	}
+}
+
+// find returns the price of the list, sorted by time, at the time t
+// according to the mode
+func find(list Prices, t time.Time, mode string) *Price {
+	if len(list) == 0 {
+		return nil
+	}
+	if t.IsZero() {
+		return list[len(list)-1]
+	}
+	// index of the first price after t
+	j := sort.Search(len(list), func(i int) bool { return list[i].Time.After(t) })
+	var before, after *Price
+	if j > 0 {
+		before = list[j-1]
+	}
+	if j < len(list) {
+		after = list[j]
+	}
+	if before != nil && before.Time.Equal(t) {
+		return before
+	}
+
+	switch mode {
+	case PriceBefore:
+		return before
+	case PriceAfter:
+		return after
+	}
+	if before == nil || after == nil {
+		if before == nil {
+			return after
+		}
+		return before
+	}
+	if mode == PriceNearest {
+		if t.Sub(before.Time) <= after.Time.Sub(t) {
+			return before
+		}
+		return after
+	}
+	return interpolate(before, after, t)
+}
+
+// interpolate returns the price at the time t, between the times of the
+// prices p1 and p2, by linear interpolation
+func interpolate(p1, p2 *Price, t time.Time) *Price {
+	// v = v1 + (v2 - v1) * (t - t1) / (t2 - t1)
+	f := big.NewRat(int64(t.Sub(p1.Time)), int64(p2.Time.Sub(p1.Time)))
+	v1, v2 := p1.Value.Rat(), p2.Value.Rat()
+	r := new(big.Rat).Sub(v2, v1)
+	r.Mul(r, f).Add(r, v1)
+
+	v, err := numeric.FromDecimal(r.FloatString(interpolatedPrecision))
+	if err != nil {
+		return p1
+	}
+	return &Price{
+		Commodity: p1.Commodity,
+		Currency:  p1.Currency,
+		Time:      t,
+		Source:    PriceSourceInterpolated,
+		Type:      p1.Type,
+		Value:     v,
+	}
+}
+
+// History returns the prices of the commodity in the currency from the
+// time from to the time to (zero values mean no limit), sorted by time.
+// The prices of the pair are returned if any, else the inverse prices of
+// the reversed pair, else the prices of the commodity in the base
+// currency converted at the time of each price. The time of a converted
+// price is the older of the times of its two prices, as returned by At.
+func (db *PriceDB) History(commodity, currency string, from, to time.Time) Prices {
+	in := func(p *Price) bool {
+		return (from.IsZero() || !p.Time.Before(from)) && (to.IsZero() || !p.Time.After(to))
+	}
+	var list Prices
+	switch {
+	case len(db.pairs[pricePair{commodity, currency}]) > 0:
+		for _, p := range db.pairs[pricePair{commodity, currency}] {
+			if in(p) {
+				list = append(list, p)
+			}
+		}
+	case len(db.pairs[pricePair{currency, commodity}]) > 0:
+		for _, p := range db.pairs[pricePair{currency, commodity}] {
+			if in(p) {
+				if inv := db.inverse(commodity, currency, p.Time, PriceBefore); inv != nil {
+					list = append(list, inv)
+				}
+			}
+		}
+	case db.Base != "" && commodity != db.Base && currency != db.Base:
+		for _, p := range db.History(commodity, db.Base, from, to) {
+			if tp, _ := db.At(commodity, currency, p.Time, PriceBefore); tp != nil {
+				list = append(list, tp)
+			}
+		}
+	}
+	return list
+}
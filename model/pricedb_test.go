@@ -0,0 +1,143 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPrice returns the price of the commodity in the currency at the date
+func testPrice(commodity, currency, date, value string) *Price {
+	return &Price{Commodity: commodity, Currency: currency, Time: ymd(date), Value: num(value)}
+}
+
+// priceString returns the value with 4 digits and the date of the price
+func priceString(p *Price) string {
+	if p == nil {
+		return "nil"
+	}
+	return p.Value.FloatString(4) + "@" + p.Time.Format("2006-01-02")
+}
+
+// testPriceDB returns the database of the prices used by the tests
+func testPriceDB() *PriceDB {
+	return NewPriceDB(Prices{
+		testPrice("ACME", "EUR", "2015-01-30", "14"),
+		testPrice("ACME", "EUR", "2015-01-10", "10"),
+		testPrice("ACME", "EUR", "2015-01-20", "12"),
+		testPrice("USD", "EUR", "2015-01-01", "0.9"),
+		testPrice("EUR", "USD", "2015-01-25", "1.25"),
+		testPrice("EUR", "GBP", "2015-01-15", "0.75"),
+	}, "EUR")
+}
+
+func TestPriceFind(t *testing.T) {
+	list := testPriceDB().pairs[pricePair{"ACME", "EUR"}]
+	tests := []struct {
+		date string // empty for the zero time
+		mode string
+		want string
+	}{
+		{"2015-01-15", PriceBefore, "10.0000@2015-01-10"},
+		{"2015-01-15", PriceAfter, "12.0000@2015-01-20"},
+		{"2015-01-20", PriceAfter, "12.0000@2015-01-20"},
+		{"2015-01-20", PriceBefore, "12.0000@2015-01-20"},
+		{"2015-01-14", PriceNearest, "10.0000@2015-01-10"},
+		{"2015-01-16", PriceNearest, "12.0000@2015-01-20"},
+		{"2015-01-15", PriceNearest, "10.0000@2015-01-10"}, // tie
+		{"2015-01-15", PriceInterpolated, "11.0000@2015-01-15"},
+		{"2015-01-22", PriceInterpolated, "12.4000@2015-01-22"},
+		{"2015-01-05", PriceBefore, "nil"},
+		{"2015-01-05", PriceAfter, "10.0000@2015-01-10"},
+		{"2015-01-05", PriceInterpolated, "10.0000@2015-01-10"},
+		{"2015-02-01", PriceAfter, "nil"},
+		{"2015-02-01", PriceInterpolated, "14.0000@2015-01-30"},
+		{"", PriceBefore, "14.0000@2015-01-30"},
+	}
+	for _, tt := range tests {
+		var d time.Time
+		if tt.date != "" {
+			d = ymd(tt.date)
+		}
+		if got := priceString(find(list, d, tt.mode)); got != tt.want {
+			t.Errorf("find(%s, %s) = %s, want %s", tt.date, tt.mode, got, tt.want)
+		}
+	}
+	if p := find(list, ymd("2015-01-15"), PriceInterpolated); p.Source != PriceSourceInterpolated {
+		t.Errorf("interpolated price source %q, want %q", p.Source, PriceSourceInterpolated)
+	}
+	if p := find(nil, ymd("2015-01-15"), PriceNearest); p != nil {
+		t.Errorf("find in an empty list = %s, want nil", priceString(p))
+	}
+}
+
+func TestPriceLookup(t *testing.T) {
+	db := testPriceDB()
+	tests := []struct {
+		commodity, currency string
+		date                string // empty for the zero time
+		mode                string
+		want                string
+		source              string
+	}{
+		{"USD", "EUR", "2015-01-10", PriceBefore, "0.9000@2015-01-01", ""},
+		{"USD", "EUR", "2015-01-31", PriceBefore, "0.8000@2015-01-25", PriceSourceInverse},
+		{"USD", "EUR", "", PriceBefore, "0.8000@2015-01-25", PriceSourceInverse},
+		{"EUR", "USD", "2015-01-10", PriceAfter, "1.2500@2015-01-25", ""},
+		{"EUR", "USD", "2015-01-05", PriceNearest, "1.1111@2015-01-01", PriceSourceInverse},
+		{"EUR", "USD", "2015-01-20", PriceNearest, "1.2500@2015-01-25", ""},
+		{"GBP", "EUR", "2015-01-20", PriceBefore, "1.3333@2015-01-15", PriceSourceInverse},
+		{"GBP", "EUR", "2015-01-10", PriceBefore, "nil", ""},
+		{"ACME", "USD", "2015-01-20", PriceBefore, "nil", ""},
+	}
+	for _, tt := range tests {
+		var d time.Time
+		if tt.date != "" {
+			d = ymd(tt.date)
+		}
+		p := db.lookup(tt.commodity, tt.currency, d, tt.mode)
+		if got := priceString(p); got != tt.want {
+			t.Errorf("lookup(%s, %s, %s, %s) = %s, want %s", tt.commodity, tt.currency, tt.date, tt.mode, got, tt.want)
+			continue
+		}
+		if p != nil && p.Source != tt.source {
+			t.Errorf("lookup(%s, %s, %s, %s) source %q, want %q", tt.commodity, tt.currency, tt.date, tt.mode, p.Source, tt.source)
+		}
+	}
+}
+
+func TestPriceHistory(t *testing.T) {
+	db := testPriceDB()
+	tests := []struct {
+		commodity, currency string
+		from, to            string // empty for no limit
+		want                string
+	}{
+		{"ACME", "EUR", "", "", "10.0000@2015-01-10 12.0000@2015-01-20 14.0000@2015-01-30"},
+		{"ACME", "EUR", "2015-01-15", "2015-01-25", "12.0000@2015-01-20"},
+		{"ACME", "EUR", "2015-01-20", "2015-01-20", "12.0000@2015-01-20"},
+		{"EUR", "USD", "", "", "1.2500@2015-01-25"},
+		{"GBP", "EUR", "", "", "1.3333@2015-01-15"},
+		// triangulated through EUR: the time is the one of the older price
+		{"ACME", "USD", "", "", "11.1111@2015-01-01 13.3333@2015-01-01 17.5000@2015-01-25"},
+		{"ACME", "USD", "2015-01-25", "", "17.5000@2015-01-25"},
+		{"ACME", "GBP", "", "2015-01-20", "9.0000@2015-01-15"},
+		{"XYZ", "EUR", "", "", ""},
+	}
+	for _, tt := range tests {
+		var from, to time.Time
+		if tt.from != "" {
+			from = ymd(tt.from)
+		}
+		if tt.to != "" {
+			to = ymd(tt.to)
+		}
+		var list []string
+		for _, p := range db.History(tt.commodity, tt.currency, from, to) {
+			list = append(list, priceString(p))
+		}
+		if got := strings.Join(list, " "); got != tt.want {
+			t.Errorf("History(%s, %s, %s, %s) = %q, want %q", tt.commodity, tt.currency, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
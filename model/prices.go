@@ -0,0 +1,77 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// PriceSourceTransaction is the source of the prices implied by the splits
+const PriceSourceTransaction = "transaction"
+
+// Prices type
+type Prices []*Price
+
+// Price type: the Value of one unit of Commodity expressed in Currency
+type Price struct {
+	ID        string
+	Commodity string
+	Currency  string
+	Time      time.Time
+	Source    string
+	Type      string
+	Value     numeric.Numeric
+}
+
+func newPriceFromXML(xmlPrice *gncxml.Price) (*Price, error) {
+	// check Time
+	t, err := timeParse(xmlPrice.Time, false)
+	if err != nil {
+		return nil, formatError("Price", "Time", xmlPrice.ID, err)
+	}
+	// check Value
+	value, err := numeric.FromString(xmlPrice.Value)
+	if err != nil {
+		return nil, formatError("Price", "Value", xmlPrice.ID, err)
+	}
+
+	price := Price{
+		ID:        xmlPrice.ID,
+		Commodity: xmlPrice.Commodity,
+		Currency:  xmlPrice.Currency,
+		Time:      t,
+		Source:    xmlPrice.Source,
+		Type:      xmlPrice.Type,
+		Value:     value,
+	}
+	return &price, nil
+}
+
+func newPricesFromXML(xmlPriceList []gncxml.Price, errs *errorCollector) (Prices, error) {
+	prices := Prices{}
+
+	for _, xmlPrice := range xmlPriceList {
+		p, err := newPriceFromXML(&xmlPrice)
+		if err != nil {
+			if err = errs.add(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		prices = append(prices, p)
+	}
+
+	// sort Prices by Time
+	sort.Sort(byPriceTime(prices))
+
+	return prices, nil
+}
+
+// used to sort Prices
+type byPriceTime []*Price
+
+func (p byPriceTime) Len() int           { return len(p) }
+func (p byPriceTime) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p byPriceTime) Less(i, j int) bool { return p[i].Time.Before(p[j].Time) }
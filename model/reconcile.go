@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Reconciled states of a split
+const (
+	StateNew        = "n"
+	StateCleared    = "c"
+	StateReconciled = "y"
+	StateFrozen     = "f"
+	StateVoided     = "v"
+)
+
+// Reconciliation type: the reconcile status of an account at a given date.
+// The balances are computed on the split quantities, i.e. in the
+// commodity of the account.
+type Reconciliation struct {
+	Account *Account
+	Date    time.Time
+
+	// LastReconcileDate is the most recent reconcile date of the
+	// reconciled splits (zero if the account was never reconciled)
+	LastReconcileDate time.Time
+
+	// ReconciledBalance is the balance of the reconciled splits
+	ReconciledBalance numeric.Numeric
+	// ClearedBalance is the balance of the cleared and reconciled splits
+	ClearedBalance numeric.Numeric
+	// PresentBalance is the balance of the splits posted up to Date
+	PresentBalance numeric.Numeric
+	// FutureBalance is the balance of all the splits, Date included
+	FutureBalance numeric.Numeric
+
+	// Cleared lists the cleared but not yet reconciled splits
+	Cleared []*AccountTransaction
+	// New lists the splits neither cleared nor reconciled
+	New []*AccountTransaction
+}
+
+// Reconciled returns true if the split was reconciled (or frozen).
+func (s *Split) Reconciled() bool {
+	return s.ReconciledState == StateReconciled || s.ReconciledState == StateFrozen
+}
+
+// Cleared returns true if the split was cleared or reconciled.
+func (s *Split) Cleared() bool {
+	return s.ReconciledState == StateCleared || s.Reconciled()
+}
+
+// Reconciliation returns the reconcile status of the account at the date.
+// The zero date means now.
+func (a *Account) Reconciliation(date time.Time) *Reconciliation {
+	if date.IsZero() {
+		date = time.Now()
+	}
+	r := &Reconciliation{Account: a, Date: date}
+
+	for _, at := range a.AccountTransactionList {
+		s := at.Split
+		q := s.Quantity
+
+		r.FutureBalance.AddEqual(&q)
+		if !at.Transaction.DatePosted.After(date) {
+			r.PresentBalance.AddEqual(&q)
+		}
+
+		switch {
+		case s.Reconciled():
+			r.ReconciledBalance.AddEqual(&q)
+			r.ClearedBalance.AddEqual(&q)
+			if s.ReconcileDate.After(r.LastReconcileDate) {
+				r.LastReconcileDate = s.ReconcileDate
+			}
+		case s.ReconciledState == StateCleared:
+			r.ClearedBalance.AddEqual(&q)
+			r.Cleared = append(r.Cleared, at)
+		case s.ReconciledState == StateNew:
+			r.New = append(r.New, at)
+		}
+	}
+	return r
+}
@@ -0,0 +1,442 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// Recurrence periods
+const (
+	PeriodOnce        = "once"
+	PeriodDay         = "day"
+	PeriodWeek        = "week"
+	PeriodMonth       = "month"
+	PeriodEndOfMonth  = "end of month"
+	PeriodNthWeekday  = "nth weekday"
+	PeriodLastWeekday = "last weekday"
+	PeriodYear        = "year"
+)
+
+// Weekend adjustments of a recurrence
+const (
+	WeekendNone    = "none"
+	WeekendBack    = "back"    // to the previous Friday
+	WeekendForward = "forward" // to the next Monday
+)
+
+// Recurrence type: a periodic schedule, every Mult Periods from Start
+type Recurrence struct {
+	Mult          int
+	Period        string
+	Start         time.Time
+	WeekendAdjust string
+}
+
+// ScheduledTransactions type
+type ScheduledTransactions []*ScheduledTransaction
+
+// ScheduledTransaction type
+type ScheduledTransaction struct {
+	ID                string
+	Name              string
+	Enabled           bool
+	AutoCreate        bool
+	AdvanceCreateDays int
+	AdvanceRemindDays int
+	InstanceCount     int       // number of the occurrences already created
+	Start             time.Time // first possible occurrence
+	End               time.Time // zero if no end date
+	Last              time.Time // last created occurrence, zero if none
+	NumOccur          int       // total number of occurrences, 0 if unlimited
+	RemOccur          int       // remaining occurrences, if NumOccur > 0
+	Schedule          []Recurrence
+	TemplateAccount   *Account
+	Templates         []*TemplateTransaction
+}
+
+// TemplateTransaction type: a transaction created by each occurrence of
+// a scheduled transaction
+type TemplateTransaction struct {
+	Transaction *Transaction
+	Splits      []*TemplateSplit
+}
+
+// TemplateSplit type: a split of a template transaction.
+// The amounts are given by the credit and debit formulas.
+type TemplateSplit struct {
+	Split         *Split
+	Account       *Account // account of the created split, nil if not found
+	CreditFormula string
+	DebitFormula  string
+}
+
+// parseGDate parses a date in the YYYY-MM-DD form (zero if empty)
+func parseGDate(value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// atoi parses an integer (zero if empty)
+func atoi(value string) (int, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(strings.TrimSpace(value))
+}
+
+func newRecurrenceFromXML(xmlRecurrence *gncxml.Recurrence, id string) (*Recurrence, error) {
+	mult, err := atoi(xmlRecurrence.Mult)
+	if err != nil {
+		return nil, formatError("Recurrence", "Mult", id, err)
+	}
+	if mult <= 0 {
+		mult = 1
+	}
+	start, err := parseGDate(xmlRecurrence.Start)
+	if err != nil {
+		return nil, formatError("Recurrence", "Start", id, err)
+	}
+	switch xmlRecurrence.PeriodType {
+	case PeriodOnce, PeriodDay, PeriodWeek, PeriodMonth, PeriodEndOfMonth, PeriodNthWeekday, PeriodLastWeekday, PeriodYear:
+	default:
+		return nil, formatError("Recurrence", "PeriodType", id, fmt.Errorf("Unknown period: %s", xmlRecurrence.PeriodType))
+	}
+	adjust := xmlRecurrence.WeekendAdj
+	if adjust == "" {
+		adjust = WeekendNone
+	}
+	return &Recurrence{
+		Mult:          mult,
+		Period:        xmlRecurrence.PeriodType,
+		Start:         start,
+		WeekendAdjust: adjust,
+	}, nil
+}
+
+func newScheduledTransactionFromXML(xmlSX *gncxml.ScheduledTransaction, book *Book) (*ScheduledTransaction, error) {
+	sx := ScheduledTransaction{
+		ID:         xmlSX.ID,
+		Name:       xmlSX.Name,
+		Enabled:    xmlSX.Enabled == "y",
+		AutoCreate: xmlSX.AutoCreate == "y",
+	}
+
+	// integer fields
+	ints := []struct {
+		field string
+		value string
+		dest  *int
+	}{
+		{"AdvanceCreateDays", xmlSX.AdvanceCreateDays, &sx.AdvanceCreateDays},
+		{"AdvanceRemindDays", xmlSX.AdvanceRemindDays, &sx.AdvanceRemindDays},
+		{"InstanceCount", xmlSX.InstanceCount, &sx.InstanceCount},
+		{"NumOccur", xmlSX.NumOccur, &sx.NumOccur},
+		{"RemOccur", xmlSX.RemOccur, &sx.RemOccur},
+	}
+	for _, f := range ints {
+		v, err := atoi(f.value)
+		if err != nil {
+			return nil, formatError("ScheduledTransaction", f.field, xmlSX.ID, err)
+		}
+		*f.dest = v
+	}
+
+	// date fields
+	dates := []struct {
+		field string
+		value string
+		dest  *time.Time
+	}{
+		{"Start", xmlSX.Start, &sx.Start},
+		{"End", xmlSX.End, &sx.End},
+		{"Last", xmlSX.Last, &sx.Last},
+	}
+	for _, f := range dates {
+		v, err := parseGDate(f.value)
+		if err != nil {
+			return nil, formatError("ScheduledTransaction", f.field, xmlSX.ID, err)
+		}
+		*f.dest = v
+	}
+
+	// schedule
+	for j := range xmlSX.Schedule {
+		r, err := newRecurrenceFromXML(&xmlSX.Schedule[j], xmlSX.ID)
+		if err != nil {
+			return nil, err
+		}
+		sx.Schedule = append(sx.Schedule, *r)
+	}
+
+	// template transactions
+	sx.TemplateAccount = book.Template.Accounts.Map[xmlSX.TemplateAccountID]
+	if sx.TemplateAccount == nil {
+		return nil, formatError("ScheduledTransaction", "TemplateAccountID", xmlSX.ID, fmt.Errorf("Template account not found: %s", xmlSX.TemplateAccountID))
+	}
+	var last *Transaction
+	for _, at := range sx.TemplateAccount.AccountTransactionList {
+		if at.Transaction == last {
+			// many splits of the same transaction
+			continue
+		}
+		last = at.Transaction
+		tt := &TemplateTransaction{Transaction: at.Transaction}
+		for _, s := range at.Transaction.Splits {
+			tt.Splits = append(tt.Splits, &TemplateSplit{
+				Split:         s,
+				Account:       book.Accounts.Map[s.Slots.Value("sched-xaction/account")],
+				CreditFormula: s.Slots.Value("sched-xaction/credit-formula"),
+				DebitFormula:  s.Slots.Value("sched-xaction/debit-formula"),
+			})
+		}
+		sx.Templates = append(sx.Templates, tt)
+	}
+
+	return &sx, nil
+}
+
+func newScheduledTransactionsFromXML(xmlSXList []gncxml.ScheduledTransaction, book *Book, errs *errorCollector) (ScheduledTransactions, error) {
+	list := ScheduledTransactions{}
+	for j := range xmlSXList {
+		sx, err := newScheduledTransactionFromXML(&xmlSXList[j], book)
+		if err != nil {
+			if err = errs.add(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		list = append(list, sx)
+	}
+	sort.Sort(byScheduledName(list))
+	return list, nil
+}
+
+// used to sort ScheduledTransactions
+type byScheduledName []*ScheduledTransaction
+
+func (a byScheduledName) Len() int           { return len(a) }
+func (a byScheduledName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byScheduledName) Less(i, j int) bool { return strings.Compare(a[i].Name, a[j].Name) < 0 }
+
+// parseFormula parses a formula that is a plain number, with either
+// the dot or the comma as decimal separator. The empty formula is zero.
+func parseFormula(formula string) (numeric.Numeric, error) {
+	s := strings.Replace(strings.TrimSpace(formula), " ", "", -1)
+	if s == "" {
+		return numeric.Numeric{}, nil
+	}
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	if comma > dot {
+		// comma decimal separator: dots are thousands separators
+		s = strings.Replace(s, ".", "", -1)
+		s = strings.Replace(s, ",", ".", 1)
+	} else {
+		s = strings.Replace(s, ",", "", -1)
+	}
+	v, err := numeric.FromDecimal(s)
+	if err != nil {
+		return v, fmt.Errorf("Not Implemented: formula %q", formula)
+	}
+	return v, nil
+}
+
+// Value returns the value of the split created by the template split:
+// debit minus credit. Only the formulas that are plain numbers are
+// supported: for other formulas the numeric slots saved by GnuCash are
+// used, if present.
+func (ts *TemplateSplit) Value() (numeric.Numeric, error) {
+	debit, err := ts.formulaValue(ts.DebitFormula, "sched-xaction/debit-numeric")
+	if err != nil {
+		return debit, err
+	}
+	credit, err := ts.formulaValue(ts.CreditFormula, "sched-xaction/credit-numeric")
+	if err != nil {
+		return credit, err
+	}
+	return numeric.Sub(&debit, &credit), nil
+}
+
+func (ts *TemplateSplit) formulaValue(formula, numericSlot string) (numeric.Numeric, error) {
+	v, err := parseFormula(formula)
+	if err == nil {
+		return v, nil
+	}
+	if s := ts.Split.Slots.Value(numericSlot); s != "" {
+		if n, err2 := numeric.FromString(s); err2 == nil {
+			return n, nil
+		}
+	}
+	return v, err
+}
+
+// String returns a description of the recurrence (e.g. "every 2 weeks").
+func (r *Recurrence) String() string {
+	if r.Period == PeriodOnce {
+		return "once on " + r.Start.Format("2006-01-02")
+	}
+	unit := r.Period
+	switch r.Period {
+	case PeriodEndOfMonth, PeriodNthWeekday, PeriodLastWeekday:
+		unit = "month"
+	}
+	var s string
+	if r.Mult == 1 {
+		s = "every " + unit
+	} else {
+		s = fmt.Sprintf("every %d %ss", r.Mult, unit)
+	}
+	switch r.Period {
+	case PeriodEndOfMonth:
+		s += " on the last day"
+	case PeriodNthWeekday:
+		s += fmt.Sprintf(" on the %s %s", ordinals[(r.Start.Day()-1)/7], r.Start.Weekday())
+	case PeriodLastWeekday:
+		s += fmt.Sprintf(" on the last %s", r.Start.Weekday())
+	}
+	if r.WeekendAdjust != WeekendNone {
+		s += " (weekend " + r.WeekendAdjust + ")"
+	}
+	return s
+}
+
+var ordinals = []string{"first", "second", "third", "fourth", "fifth"}
+
+// daysIn returns the number of days of the month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// addMonths adds n months to t, clamping the day to the end of the month
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	day := t.Day()
+	if last := daysIn(first.Year(), first.Month()); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// nth returns the n-th date (from 0) of the recurrence, before the
+// weekend adjustment. The second result is false if there is no n-th date.
+func (r *Recurrence) nth(n int) (time.Time, bool) {
+	s := r.Start
+	switch r.Period {
+	case PeriodOnce:
+		return s, n == 0
+	case PeriodDay:
+		return s.AddDate(0, 0, n*r.Mult), true
+	case PeriodWeek:
+		return s.AddDate(0, 0, 7*n*r.Mult), true
+	case PeriodMonth:
+		return addMonths(s, n*r.Mult), true
+	case PeriodYear:
+		return addMonths(s, 12*n*r.Mult), true
+	}
+
+	// periods relative to the month
+	m := addMonths(time.Date(s.Year(), s.Month(), 1, 0, 0, 0, 0, s.Location()), n*r.Mult)
+	last := daysIn(m.Year(), m.Month())
+	switch r.Period {
+	case PeriodEndOfMonth:
+		return m.AddDate(0, 0, last-1), true
+	case PeriodNthWeekday:
+		first := 1 + (int(s.Weekday())-int(m.Weekday())+7)%7
+		day := first + 7*((s.Day()-1)/7)
+		if day > last {
+			day -= 7
+		}
+		return m.AddDate(0, 0, day-1), true
+	case PeriodLastWeekday:
+		end := m.AddDate(0, 0, last-1)
+		return end.AddDate(0, 0, -((int(end.Weekday()) - int(s.Weekday()) + 7) % 7)), true
+	}
+	return time.Time{}, false
+}
+
+// maxAdjust is the maximum number of days a date is moved by adjust
+const maxAdjust = 2
+
+// adjust moves the date out of the weekend, if required
+func (r *Recurrence) adjust(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		switch r.WeekendAdjust {
+		case WeekendBack:
+			return t.AddDate(0, 0, -1)
+		case WeekendForward:
+			return t.AddDate(0, 0, 2)
+		}
+	case time.Sunday:
+		switch r.WeekendAdjust {
+		case WeekendBack:
+			return t.AddDate(0, 0, -2)
+		case WeekendForward:
+			return t.AddDate(0, 0, 1)
+		}
+	}
+	return t
+}
+
+// occurrences returns all the occurrences of the scheduled transaction
+// from Start up to to, sorted by date, without considering Last.
+func (sx *ScheduledTransaction) occurrences(to time.Time) []time.Time {
+	if !sx.End.IsZero() && sx.End.Before(to) {
+		to = sx.End
+	}
+	seen := map[time.Time]bool{}
+	var list []time.Time
+	for j := range sx.Schedule {
+		r := &sx.Schedule[j]
+		for n := 0; ; n++ {
+			d, ok := r.nth(n)
+			if !ok || d.After(to.AddDate(0, 0, maxAdjust)) {
+				break
+			}
+			// the adjusted date can be back within to
+			d = r.adjust(d)
+			if d.Before(sx.Start) || d.After(to) || seen[d] {
+				continue
+			}
+			seen[d] = true
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Before(list[j]) })
+	if sx.NumOccur > 0 && len(list) > sx.NumOccur {
+		list = list[:sx.NumOccur]
+	}
+	return list
+}
+
+// NextOccurrences returns the dates of the occurrences of the scheduled
+// transaction in [from, to] following the last created occurrence,
+// sorted by date. Disabled scheduled transactions have no occurrences;
+// with a limited number of occurrences, at most RemOccur are left.
+func (sx *ScheduledTransaction) NextOccurrences(from, to time.Time) []time.Time {
+	if !sx.Enabled {
+		return nil
+	}
+	var list []time.Time
+	next := 0 // occurrences after Last
+	for _, d := range sx.occurrences(to) {
+		if !sx.Last.IsZero() && !d.After(sx.Last) {
+			continue
+		}
+		if sx.NumOccur > 0 && next == sx.RemOccur {
+			break
+		}
+		next++
+		if !d.Before(from) {
+			list = append(list, d)
+		}
+	}
+	return list
+}
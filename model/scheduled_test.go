@@ -0,0 +1,131 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// ymd returns the date of the YYYY-MM-DD string in UTC
+func ymd(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// dates returns the dates in the YYYY-MM-DD form
+func dates(list []time.Time) string {
+	s := make([]string, len(list))
+	for j, t := range list {
+		s[j] = t.Format("2006-01-02")
+	}
+	return strings.Join(s, " ")
+}
+
+func TestRecurrenceNth(t *testing.T) {
+	tests := []struct {
+		period string
+		mult   int
+		start  string
+		n      int
+		want   string // empty if there is no n-th date
+	}{
+		{PeriodOnce, 1, "2015-01-31", 0, "2015-01-31"},
+		{PeriodOnce, 1, "2015-01-31", 1, ""},
+		{PeriodDay, 3, "2015-01-01", 2, "2015-01-07"},
+		{PeriodWeek, 2, "2015-01-01", 1, "2015-01-15"},
+		{PeriodMonth, 1, "2015-01-31", 1, "2015-02-28"},
+		{PeriodMonth, 1, "2015-01-31", 2, "2015-03-31"},
+		{PeriodMonth, 3, "2015-11-30", 1, "2016-02-29"},
+		{PeriodYear, 1, "2016-02-29", 1, "2017-02-28"},
+		{PeriodYear, 4, "2016-02-29", 1, "2020-02-29"},
+		{PeriodEndOfMonth, 1, "2015-01-15", 1, "2015-02-28"},
+		{PeriodEndOfMonth, 1, "2015-01-15", 0, "2015-01-31"},
+		{PeriodNthWeekday, 1, "2015-01-13", 1, "2015-02-10"},  // second Tuesday
+		{PeriodNthWeekday, 1, "2015-01-29", 1, "2015-02-26"},  // fifth Thursday, four in February
+		{PeriodLastWeekday, 1, "2015-01-30", 1, "2015-02-27"}, // last Friday
+		{PeriodLastWeekday, 2, "2015-01-30", 1, "2015-03-27"},
+		{"unknown", 1, "2015-01-01", 0, ""},
+	}
+	for _, tt := range tests {
+		r := &Recurrence{Mult: tt.mult, Period: tt.period, Start: ymd(tt.start)}
+		d, ok := r.nth(tt.n)
+		got := ""
+		if ok {
+			got = d.Format("2006-01-02")
+		}
+		if got != tt.want {
+			t.Errorf("nth(%d) of %s every %d from %s = %q, want %q", tt.n, tt.period, tt.mult, tt.start, got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceAdjust(t *testing.T) {
+	tests := []struct {
+		date, adjust, want string
+	}{
+		{"2015-02-28", WeekendBack, "2015-02-27"}, // Saturday
+		{"2015-02-28", WeekendForward, "2015-03-02"},
+		{"2015-03-01", WeekendBack, "2015-02-27"}, // Sunday
+		{"2015-03-01", WeekendForward, "2015-03-02"},
+		{"2015-02-28", WeekendNone, "2015-02-28"},
+		{"2015-03-02", WeekendBack, "2015-03-02"}, // Monday
+	}
+	for _, tt := range tests {
+		r := &Recurrence{WeekendAdjust: tt.adjust}
+		if got := r.adjust(ymd(tt.date)).Format("2006-01-02"); got != tt.want {
+			t.Errorf("adjust(%s) %s = %s, want %s", tt.date, tt.adjust, got, tt.want)
+		}
+	}
+}
+
+func TestNextOccurrences(t *testing.T) {
+	// monthly on the last day, moved back from the weekend:
+	// 2015-01-31 and 2015-02-28 are Saturdays
+	schedule := []Recurrence{{Mult: 1, Period: PeriodMonth, Start: ymd("2015-01-31"), WeekendAdjust: WeekendBack}}
+	tests := []struct {
+		name     string
+		sx       ScheduledTransaction
+		from, to string
+		want     string
+	}{
+		{"adjusted back within to",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), Schedule: schedule},
+			"", "2015-02-27", "2015-01-30 2015-02-27"},
+		{"after last",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), Last: ymd("2015-01-30"), Schedule: schedule},
+			"", "2015-04-30", "2015-02-27 2015-03-31 2015-04-30"},
+		{"from",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), Schedule: schedule},
+			"2015-02-01", "2015-03-31", "2015-02-27 2015-03-31"},
+		{"end date",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), End: ymd("2015-03-15"), Schedule: schedule},
+			"", "2015-12-31", "2015-01-30 2015-02-27"},
+		{"remaining occurrences",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), Last: ymd("2015-01-30"), NumOccur: 12, RemOccur: 2, Schedule: schedule},
+			"", "2015-12-31", "2015-02-27 2015-03-31"},
+		{"remaining occurrences before from",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), Last: ymd("2015-01-30"), NumOccur: 12, RemOccur: 2, Schedule: schedule},
+			"2015-03-01", "2015-12-31", "2015-03-31"},
+		{"no remaining occurrences",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), Last: ymd("2015-01-30"), NumOccur: 1, RemOccur: 0, Schedule: schedule},
+			"", "2015-12-31", ""},
+		{"total occurrences",
+			ScheduledTransaction{Enabled: true, Start: ymd("2015-01-01"), NumOccur: 3, RemOccur: 3, Schedule: schedule},
+			"", "2015-12-31", "2015-01-30 2015-02-27 2015-03-31"},
+		{"disabled",
+			ScheduledTransaction{Enabled: false, Start: ymd("2015-01-01"), Schedule: schedule},
+			"", "2015-12-31", ""},
+	}
+	for _, tt := range tests {
+		var from time.Time
+		if tt.from != "" {
+			from = ymd(tt.from)
+		}
+		if got := dates(tt.sx.NextOccurrences(from, ymd(tt.to))); got != tt.want {
+			t.Errorf("%s: NextOccurrences = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"strings"
+
+	gncxml "github.com/mmbros/gnucash-viewer/xml"
+)
+
+// Slots type
+type Slots []*Slot
+
+// Slot type: a key-value pair. Slots of type "frame" contain other slots.
+type Slot struct {
+	Key   string
+	Type  string
+	Value string
+	Slots Slots
+}
+
+// SlotSeparator is the separator of the keys in a slot path
+const SlotSeparator = "/"
+
+// NewSlotsFromXML returns the slots of an XML record not handled by the
+// model (e.g. the business data).
+func NewSlotsFromXML(xmlSlotList []gncxml.Slot) Slots {
+	return newSlotsFromXML(xmlSlotList)
+}
+
+func newSlotsFromXML(xmlSlotList []gncxml.Slot) Slots {
+	if len(xmlSlotList) == 0 {
+		return nil
+	}
+	slots := make(Slots, 0, len(xmlSlotList))
+	for _, xmlSlot := range xmlSlotList {
+		slot := Slot{
+			Key:  xmlSlot.Key,
+			Type: xmlSlot.Value.Type,
+		}
+		switch slot.Type {
+		case "frame":
+			slot.Slots = newSlotsFromXML(xmlSlot.Value.Slots)
+		case "gdate":
+			slot.Value = xmlSlot.Value.GDate
+		case "timespec":
+			slot.Value = xmlSlot.Value.TimeSpec
+		default:
+			slot.Value = strings.TrimSpace(xmlSlot.Value.Text)
+		}
+		slots = append(slots, &slot)
+	}
+	return slots
+}
+
+// Get returns the slot with the given path (e.g. "tax-US/code"),
+// or nil if not found.
+func (slots Slots) Get(path string) *Slot {
+	keys := strings.Split(path, SlotSeparator)
+	for {
+		var found *Slot
+		for _, slot := range slots {
+			if slot.Key == keys[0] {
+				found = slot
+				break
+			}
+		}
+		if found == nil || len(keys) == 1 {
+			return found
+		}
+		slots, keys = found.Slots, keys[1:]
+	}
+}
+
+// Value returns the value of the slot with the given path,
+// or the empty string if not found.
+func (slots Slots) Value(path string) string {
+	if slot := slots.Get(path); slot != nil {
+		return slot.Value
+	}
+	return ""
+}
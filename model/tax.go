@@ -0,0 +1,143 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// TaxGroup type: the splits of a year with the same tax code, or of a
+// tax-related account without code
+type TaxGroup struct {
+	Scheme   string   // e.g. "US" for the tax-US codes, empty if no code
+	Code     string   // e.g. "N256", empty if no code
+	Account  *Account // the account of the group without code
+	Accounts []*Account
+	Total    numeric.Numeric
+	Items    []*AccountTransaction // sorted by date posted
+}
+
+// TaxReport type: the amounts of the tax-related accounts in a period.
+// The amounts have the natural sign of the accounts, so that both
+// incomes and expenses are positive.
+type TaxReport struct {
+	From   time.Time
+	To     time.Time
+	Groups []*TaxGroup // codes first, sorted by scheme and code, then the accounts
+}
+
+// taxSlotPrefix is the prefix of the slots with the tax codes (tax-US, tax-IT, ...)
+const taxSlotPrefix = "tax-"
+
+// TaxRelated returns true if the account is flagged as tax-related or
+// has a tax code.
+func (a *Account) TaxRelated() bool {
+	if v := a.Slots.Value("tax-related"); v == "1" || v == "true" {
+		return true
+	}
+	_, code := a.TaxCode()
+	return code != ""
+}
+
+// TaxCode returns the tax code of the account and its scheme, taken from
+// the slot tax-<scheme>/code (e.g. tax-US/code). The tax-US code has
+// precedence; empty strings are returned if the account has no code.
+func (a *Account) TaxCode() (scheme, code string) {
+	if code := a.Slots.Value("tax-US/code"); code != "" {
+		return "US", code
+	}
+	for _, slot := range a.Slots {
+		if slot.Type != "frame" || !strings.HasPrefix(slot.Key, taxSlotPrefix) {
+			continue
+		}
+		if code := slot.Slots.Value("code"); code != "" {
+			return strings.TrimPrefix(slot.Key, taxSlotPrefix), code
+		}
+	}
+	return "", ""
+}
+
+// TaxReport returns the splits of the tax-related accounts from the day
+// from to the day to, grouped by tax code or, without code, by account.
+// Groups without splits are omitted.
+func (book *Book) TaxReport(from, to time.Time) *TaxReport {
+	r := &TaxReport{From: from, To: to}
+	groups := map[string]*TaxGroup{}
+	var byAccount []*TaxGroup
+
+	book.Accounts.Walk(func(a *Account, level int) {
+		if !a.TaxRelated() {
+			return
+		}
+		var items []*AccountTransaction
+		var total numeric.Numeric
+		for _, at := range a.AccountTransactionList {
+			d := day(at.Transaction.DatePosted)
+			if d.Before(from) || d.After(to) {
+				continue
+			}
+			items = append(items, at)
+			v := at.NaturalValue()
+			total.AddEqual(&v)
+		}
+		if len(items) == 0 {
+			return
+		}
+
+		scheme, code := a.TaxCode()
+		var g *TaxGroup
+		if code == "" {
+			g = &TaxGroup{Account: a}
+			byAccount = append(byAccount, g)
+		} else if g = groups[scheme+"/"+code]; g == nil {
+			g = &TaxGroup{Scheme: scheme, Code: code}
+			groups[scheme+"/"+code] = g
+			r.Groups = append(r.Groups, g)
+		}
+		g.Accounts = append(g.Accounts, a)
+		g.Items = append(g.Items, items...)
+		g.Total.AddEqual(&total)
+	})
+
+	sort.Sort(byTaxCode(r.Groups))
+	r.Groups = append(r.Groups, byAccount...)
+	for _, g := range r.Groups {
+		sort.SliceStable(g.Items, func(i, j int) bool {
+			return g.Items[i].Transaction.DatePosted.Before(g.Items[j].Transaction.DatePosted)
+		})
+	}
+	return r
+}
+
+// Name returns the code of the group, or the full name of its account.
+func (g *TaxGroup) Name() string {
+	if g.Code != "" {
+		return g.Scheme + " " + g.Code
+	}
+	return g.Account.FullName()
+}
+
+// NaturalValue returns the amount of the split in the commodity of its
+// account, with the natural sign of the account: positive for incomes
+// and expenses alike.
+func (at *AccountTransaction) NaturalValue() numeric.Numeric {
+	v := at.Split.Quantity
+	if at.Split.Account.Type.InvertValues() {
+		v.NegEqual()
+	}
+	return v
+}
+
+// used to sort TaxGroups
+type byTaxCode []*TaxGroup
+
+func (a byTaxCode) Len() int      { return len(a) }
+func (a byTaxCode) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byTaxCode) Less(i, j int) bool {
+	if a[i].Scheme != a[j].Scheme {
+		return a[i].Scheme < a[j].Scheme
+	}
+	return a[i].Code < a[j].Code
+}
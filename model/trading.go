@@ -0,0 +1,67 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// tradingAccountsSlot is the book option enabling the trading accounts
+const tradingAccountsSlot = "options/Accounts/Use Trading Accounts"
+
+// IsTrading returns true if the account is a TRADING account.
+func (a *Account) IsTrading() bool {
+	return a.Type.Code() == "TRADING"
+}
+
+// UsesTradingAccounts returns true if the book has the trading accounts
+// option set, or has TRADING accounts. In such books each transaction
+// balances in every commodity, not only in its currency.
+func (book *Book) UsesTradingAccounts() bool {
+	if v := book.Slots.Value(tradingAccountsSlot); v == "t" || v == "true" {
+		return true
+	}
+	for _, a := range book.Accounts.Map {
+		if a.IsTrading() {
+			return true
+		}
+	}
+	return false
+}
+
+// Currency returns the currency of the book: the currency of the root
+// account if it has one, else the most used transaction currency.
+func (book *Book) Currency() string {
+	if root := book.Accounts.Root; root != nil && root.Currency != "" {
+		return root.Currency
+	}
+	count := map[string]int{}
+	for _, t := range book.Transactions {
+		count[t.Currency]++
+	}
+	currencies := make([]string, 0, len(count))
+	for c := range count {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	currency := ""
+	for _, c := range currencies {
+		if currency == "" || count[c] > count[currency] {
+			currency = c
+		}
+	}
+	return currency
+}
+
+// CommodityBalances returns the sum of the split quantities of the
+// transaction for each commodity of the split accounts. With trading
+// accounts every sum is zero.
+func (t *Transaction) CommodityBalances() map[string]numeric.Numeric {
+	sums := map[string]numeric.Numeric{}
+	for _, s := range t.Splits {
+		v := sums[s.Account.Currency]
+		v.AddEqual(&s.Quantity)
+		sums[s.Account.Currency] = v
+	}
+	return sums
+}
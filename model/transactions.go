@@ -2,7 +2,6 @@ package model
 
 import (
 	"errors"
-	"fmt"
 	"sort"
 	"time"
 
@@ -20,6 +19,7 @@ type Transaction struct {
 	DatePosted  time.Time
 	DateEntered time.Time
 	Description string
+	Slots       Slots
 	Splits      []*Split
 }
 
@@ -32,6 +32,13 @@ type Split struct {
 	Memo            string
 	Quantity        numeric.Numeric
 	Account         *Account
+	Lot             *Lot // nil if the split is not in a lot
+	Slots           Slots
+}
+
+// Notes returns the notes of the transaction.
+func (t *Transaction) Notes() string {
+	return t.Slots.Value("notes")
 }
 
 func timeParse(value string, nullable bool) (time.Time, error) {
@@ -41,10 +48,6 @@ func timeParse(value string, nullable bool) (time.Time, error) {
 	return time.Parse("2006-01-02 15:04:05 -0700", value)
 }
 
-func formatError(object, field, id string, err error) error {
-	return fmt.Errorf("Invalid %s in %s (ID=%s): %s", field, object, id, err.Error())
-}
-
 func newSplitFromXML(xmlSplit *gncxml.Split, accounts *Accounts) (*Split, error) {
 	// check ReconcileDate
 	reconcileDate, err := timeParse(xmlSplit.ReconcileDate, true)
@@ -66,6 +69,13 @@ func newSplitFromXML(xmlSplit *gncxml.Split, accounts *Accounts) (*Split, error)
 	if !ok {
 		return nil, formatError("Split", "AccountID", xmlSplit.ID, errors.New("Account not found"))
 	}
+	// check Lot
+	var lot *Lot
+	if len(xmlSplit.LotID) > 0 {
+		if lot = account.Lot(xmlSplit.LotID); lot == nil {
+			return nil, formatError("Split", "LotID", xmlSplit.ID, errors.New("Lot not found"))
+		}
+	}
 
 	// initialize Transaction object
 	split := Split{
@@ -76,6 +86,8 @@ func newSplitFromXML(xmlSplit *gncxml.Split, accounts *Accounts) (*Split, error)
 		Memo:            xmlSplit.Memo,
 		Quantity:        quantity,
 		Account:         account,
+		Lot:             lot,
+		Slots:           newSlotsFromXML(xmlSplit.Slots),
 	}
 
 	return &split, nil
@@ -97,7 +109,7 @@ func newTransactionFromXML(xmlTransaction *gncxml.Transaction, accounts *Account
 	for _, xmlSplit := range xmlTransaction.SplitList {
 		split, err := newSplitFromXML(&xmlSplit, accounts)
 		if err != nil {
-			return nil, err
+			return nil, causeError("Transaction", xmlTransaction.ID, err)
 		}
 		splits = append(splits, split)
 	}
@@ -109,13 +121,14 @@ func newTransactionFromXML(xmlTransaction *gncxml.Transaction, accounts *Account
 		DatePosted:  datePosted,
 		DateEntered: dateEntered,
 		Description: xmlTransaction.Description,
+		Slots:       newSlotsFromXML(xmlTransaction.Slots),
 		Splits:      splits,
 	}
 
 	return &transaction, nil
 }
 
-func newTransactionsFromXML(xmlTransactionList []gncxml.Transaction, accounts *Accounts) (Transactions, error) {
+func newTransactionsFromXML(xmlTransactionList []gncxml.Transaction, accounts *Accounts, errs *errorCollector) (Transactions, error) {
 	// step 0: allocate Transactions object
 	transactions := Transactions{}
 
@@ -123,7 +136,11 @@ func newTransactionsFromXML(xmlTransactionList []gncxml.Transaction, accounts *A
 	for _, xmlTransaction := range xmlTransactionList {
 		t, err := newTransactionFromXML(&xmlTransaction, accounts)
 		if err != nil {
-			return nil, err
+			// in lenient mode the whole transaction is skipped
+			if err = errs.add(err); err != nil {
+				return nil, err
+			}
+			continue
 		}
 		transactions = append(transactions, t)
 	}
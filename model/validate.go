@@ -0,0 +1,248 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+// Severity of a validation problem
+type Severity int
+
+// Severities, from the less to the most severe
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = []string{"info", "warning", "error"}
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity returns the severity with the given name.
+func ParseSeverity(name string) (Severity, error) {
+	for j, n := range severityNames {
+		if strings.EqualFold(n, name) {
+			return Severity(j), nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid severity: %s", name)
+}
+
+// Problem codes
+const (
+	ProblemUnbalanced        = "unbalanced-transaction"
+	ProblemQuantityValue     = "quantity-value-mismatch"
+	ProblemImbalanceAccount  = "imbalance-account"
+	ProblemFutureTransaction = "future-transaction"
+	ProblemPlaceholderSplit  = "placeholder-split"
+	ProblemDuplicateID       = "duplicate-id"
+	ProblemMissingCommodity  = "missing-commodity"
+	ProblemCommodityBalance  = "unbalanced-commodity"
+)
+
+// Problem type: an integrity problem of the book
+type Problem struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Object   string   `json:"object"`
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+}
+
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s ID=%s)", p.Severity, p.Code, p.Message, p.Object, p.ID)
+}
+
+// validator type: collects the problems of a book
+type validator struct {
+	book     *Book
+	now      time.Time
+	problems []*Problem
+}
+
+func (v *validator) add(severity Severity, code, object, id, format string, args ...interface{}) {
+	v.problems = append(v.problems, &Problem{
+		Severity: severity,
+		Code:     code,
+		Object:   object,
+		ID:       id,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate checks the integrity of the book and returns all the problems
+// found, the most severe first and then by code. Transactions posted
+// after now are reported as future transactions (zero now means time.Now()).
+func (book *Book) Validate(now time.Time) []*Problem {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	v := &validator{book: book, now: now}
+	v.checkIDs()
+	v.checkCommodities()
+	v.checkAccounts()
+	v.checkTransactions()
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		pi, pj := v.problems[i], v.problems[j]
+		if pi.Severity != pj.Severity {
+			return pi.Severity > pj.Severity
+		}
+		if pi.Code != pj.Code {
+			return pi.Code < pj.Code
+		}
+		return pi.ID < pj.ID
+	})
+	return v.problems
+}
+
+// checkIDs checks that each GUID identifies a single object
+func (v *validator) checkIDs() {
+	kinds := map[string]string{}
+	check := func(object, id string) {
+		if id == "" {
+			return
+		}
+		if other, ok := kinds[id]; ok {
+			v.add(SeverityError, ProblemDuplicateID, object, id, "ID already used by a %s", other)
+			return
+		}
+		kinds[id] = object
+	}
+	for _, a := range v.book.Accounts.Map {
+		check("Account", a.ID)
+	}
+	for _, t := range v.book.Transactions {
+		check("Transaction", t.ID)
+		for _, s := range t.Splits {
+			check("Split", s.ID)
+		}
+	}
+	for _, p := range v.book.Prices {
+		check("Price", p.ID)
+	}
+}
+
+// checkCommodities checks that the commodities used by accounts,
+// transactions and prices are defined in the book
+func (v *validator) checkCommodities() {
+	missing := func(id string) bool {
+		_, ok := v.book.Commodities[id]
+		return !ok
+	}
+	for _, a := range v.book.Accounts.Map {
+		if a.Parent != nil && missing(a.Currency) {
+			v.add(SeverityError, ProblemMissingCommodity, "Account", a.ID, "commodity %q of account %s not found", a.Currency, a.FullName())
+		}
+	}
+	for _, t := range v.book.Transactions {
+		if missing(t.Currency) {
+			v.add(SeverityError, ProblemMissingCommodity, "Transaction", t.ID, "currency %q of transaction %q not found", t.Currency, t.Description)
+		}
+	}
+	for _, p := range v.book.Prices {
+		if missing(p.Commodity) {
+			v.add(SeverityError, ProblemMissingCommodity, "Price", p.ID, "commodity %q not found", p.Commodity)
+		}
+		if missing(p.Currency) {
+			v.add(SeverityError, ProblemMissingCommodity, "Price", p.ID, "currency %q not found", p.Currency)
+		}
+	}
+}
+
+// checkAccounts checks the use of placeholder and imbalance accounts
+func (v *validator) checkAccounts() {
+	for _, a := range v.book.Accounts.Map {
+		n := len(a.AccountTransactionList)
+		if n == 0 {
+			continue
+		}
+		if a.Placeholder() {
+			v.add(SeverityWarning, ProblemPlaceholderSplit, "Account", a.ID, "placeholder account %s has %d splits", a.FullName(), n)
+		}
+		if a.Parent == v.book.Accounts.Root && isImbalanceName(a.Name) {
+			balance := a.Balance()
+			v.add(SeverityWarning, ProblemImbalanceAccount, "Account", a.ID, "account %s has %d splits, balance %s", a.FullName(), n, balance.DecimalString(2))
+		}
+	}
+}
+
+// imbalancePrefixes are the name prefixes, in English and Italian, of the
+// top level accounts GnuCash creates for the unbalanced and orphan splits
+// (e.g. Imbalance-EUR)
+var imbalancePrefixes = []string{"Imbalance-", "Orphan-", "Sbilancio-", "Orfano-"}
+
+// isImbalanceName returns true if the name is the name of an imbalance or
+// orphan account
+func isImbalanceName(name string) bool {
+	for _, prefix := range imbalancePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransactions checks balance, quantities and dates of the
+// transactions. With trading accounts, the balance of each commodity
+// other than the transaction currency is checked too.
+func (v *validator) checkTransactions() {
+	trading := v.book.UsesTradingAccounts()
+	for _, t := range v.book.Transactions {
+		var sum numeric.Numeric
+		for _, s := range t.Splits {
+			value := s.Value
+			sum.AddEqual(&value)
+			if s.Account.Currency == t.Currency && numeric.Cmp(&s.Quantity, &s.Value) != 0 {
+				v.add(SeverityError, ProblemQuantityValue, "Split", s.ID, "quantity %s differs from value %s in account %s of transaction %q",
+					s.Quantity.DecimalString(2), s.Value.DecimalString(2), s.Account.FullName(), t.Description)
+			}
+		}
+		if sum.Sign() != 0 {
+			v.add(SeverityError, ProblemUnbalanced, "Transaction", t.ID, "transaction %q of %s is unbalanced by %s %s",
+				t.Description, t.DatePosted.Format("2006-01-02"), sum.DecimalString(2), t.Currency)
+		}
+		if trading {
+			v.checkCommodityBalances(t)
+		}
+		if t.DatePosted.After(v.now) {
+			v.add(SeverityWarning, ProblemFutureTransaction, "Transaction", t.ID, "transaction %q is posted in the future (%s)",
+				t.Description, t.DatePosted.Format("2006-01-02"))
+		}
+	}
+}
+
+// checkCommodityBalances checks that the quantities of each commodity of
+// the transaction, other than its currency, sum to zero
+func (v *validator) checkCommodityBalances(t *Transaction) {
+	sums := t.CommodityBalances()
+	commodities := make([]string, 0, len(sums))
+	for c := range sums {
+		commodities = append(commodities, c)
+	}
+	sort.Strings(commodities)
+	for _, c := range commodities {
+		sum := sums[c]
+		if c == t.Currency || sum.Sign() == 0 {
+			continue
+		}
+		v.add(SeverityError, ProblemCommodityBalance, "Transaction", t.ID, "transaction %q of %s is unbalanced by %s %s: trading split missing",
+			t.Description, t.DatePosted.Format("2006-01-02"), sum.DecimalString(2), c)
+	}
+}
@@ -0,0 +1,105 @@
+package numeric
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// Allocate divides total into parts proportional to the given ratios.
+// The parts are expressed with the denominator of total and always sum
+// exactly to total (largest-remainder method).
+func Allocate(total *Numeric, ratios ...int64) ([]Numeric, error) {
+	den := int64(total.den)
+	if den == 0 {
+		den = 1
+	}
+	return AllocateDen(total, den, ratios...)
+}
+
+// AllocateDen divides total into parts proportional to the given ratios,
+// rounding each part to the denominator den (i.e. the commodity's smallest
+// unit). The rounded parts always sum exactly to total.
+//
+// Any unit left over after truncation is given, one at a time, to the parts
+// with the largest remainder; ties go to the first part. It returns an
+// error if total is not an exact multiple of 1/den (e.g. 1.005 with den
+// 100): round total first to allocate it anyway.
+func AllocateDen(total *Numeric, den int64, ratios ...int64) ([]Numeric, error) {
+	if den <= 0 {
+		return nil, errors.New("Denominator must be positive")
+	}
+	if len(ratios) == 0 {
+		return nil, errors.New("At least one ratio is required")
+	}
+	sumRatios := new(big.Int)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, errors.New("Ratios must be not negative")
+		}
+		sumRatios.Add(sumRatios, big.NewInt(r))
+	}
+	if sumRatios.Sign() == 0 {
+		return nil, errors.New("Sum of ratios must be positive")
+	}
+
+	// express total as an integer number of units of 1/den
+	units, ok := total.units(den)
+	if !ok {
+		return nil, errors.New("Total is not representable with the given denominator")
+	}
+	neg := units.Sign() < 0
+	units.Abs(units)
+
+	// step 1: truncated share of each part
+	type share struct {
+		index int
+		rem   *big.Int
+	}
+	parts := make([]*big.Int, len(ratios))
+	shares := make([]share, len(ratios))
+	assigned := new(big.Int)
+	for j, r := range ratios {
+		q, m := new(big.Int), new(big.Int)
+		q.Mul(units, big.NewInt(r))
+		q.QuoRem(q, sumRatios, m)
+		parts[j] = q
+		shares[j] = share{index: j, rem: m}
+		assigned.Add(assigned, q)
+	}
+
+	// step 2: give the leftover units to the largest remainders
+	left := new(big.Int).Sub(units, assigned).Int64() // always < len(ratios)
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].rem.Cmp(shares[j].rem) > 0
+	})
+	for j := int64(0); j < left; j++ {
+		p := parts[shares[j].index]
+		p.Add(p, big.NewInt(1))
+	}
+
+	// step 3: build the result
+	res := make([]Numeric, len(parts))
+	for j, p := range parts {
+		if neg {
+			p.Neg(p)
+		}
+		if !p.IsInt64() {
+			return nil, errors.New("Overflow")
+		}
+		res[j] = New(numint(p.Int64()), numint(den))
+	}
+	return res, nil
+}
+
+// units returns z as an integer number of units of 1/den.
+// The returned bool is false if z can't be exactly represented.
+func (z *Numeric) units(den int64) (*big.Int, bool) {
+	if z.den == 0 {
+		return new(big.Int), true
+	}
+	u, m := new(big.Int), new(big.Int)
+	u.Mul(big.NewInt(int64(z.num)), big.NewInt(den))
+	u.QuoRem(u, big.NewInt(int64(z.den)), m)
+	return u, m.Sign() == 0
+}
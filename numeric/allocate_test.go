@@ -0,0 +1,99 @@
+package numeric
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fractions returns the parts in the "num/den" form
+func fractions(parts []Numeric) string {
+	list := make([]string, len(parts))
+	for j, p := range parts {
+		list[j] = fmt.Sprintf("%d/%d", p.num, p.den)
+	}
+	return strings.Join(list, " ")
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  string
+		ratios []int64
+		want   string
+	}{
+		{"exact", "300/100", []int64{1, 1, 1}, "100/100 100/100 100/100"},
+		{"largest remainder", "100/100", []int64{1, 1, 1}, "34/100 33/100 33/100"},
+		{"largest remainder not first", "10/100", []int64{1, 2, 4}, "1/100 3/100 6/100"},
+		{"ties to the first part", "2/100", []int64{1, 1, 1}, "1/100 1/100 0/100"},
+		{"negative total", "-100/100", []int64{1, 1, 1}, "-34/100 -33/100 -33/100"},
+		{"zero ratio", "100/100", []int64{1, 0, 1}, "50/100 0/100 50/100"},
+		{"zero total", "0/100", []int64{1, 2}, "0/100 0/100"},
+		{"single part", "1234/100", []int64{7}, "1234/100"},
+		{"integer total", "7/1", []int64{1, 1}, "4/1 3/1"},
+	}
+	for _, tt := range tests {
+		total, err := FromString(tt.total)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		parts, err := Allocate(&total, tt.ratios...)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got := fractions(parts); got != tt.want {
+			t.Errorf("%s: Allocate(%s, %v) = %s, want %s", tt.name, tt.total, tt.ratios, got, tt.want)
+		}
+
+		// the parts always sum to the total
+		var sum Numeric
+		for j := range parts {
+			sum.AddEqual(&parts[j])
+		}
+		if d := Sub(&sum, &total); d.Sign() != 0 {
+			t.Errorf("%s: sum of the parts %s, want %s", tt.name, fractions([]Numeric{sum}), tt.total)
+		}
+	}
+}
+
+func TestAllocateDen(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  string
+		den    int64
+		ratios []int64
+		want   string
+		err    bool
+	}{
+		{"finer denominator", "1/1", 100, []int64{1, 2}, "33/100 67/100", false},
+		{"coarser denominator", "500/100", 1, []int64{1, 1}, "3/1 2/1", false},
+		{"negative total", "-1/1", 1000, []int64{1, 1, 1}, "-334/1000 -333/1000 -333/1000", false},
+		{"total not representable", "1/3", 100, []int64{1, 1}, "", true},
+		{"total with more digits", "1005/1000", 100, []int64{1}, "", true},
+		{"invalid denominator", "1/1", 0, []int64{1}, "", true},
+		{"no ratios", "1/1", 100, nil, "", true},
+		{"negative ratio", "1/1", 100, []int64{1, -1}, "", true},
+		{"all ratios zero", "1/1", 100, []int64{0, 0}, "", true},
+	}
+	for _, tt := range tests {
+		total, err := FromString(tt.total)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		parts, err := AllocateDen(&total, tt.den, tt.ratios...)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: AllocateDen(%s, %d, %v) = %s, want an error", tt.name, tt.total, tt.den, tt.ratios, fractions(parts))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got := fractions(parts); got != tt.want {
+			t.Errorf("%s: AllocateDen(%s, %d, %v) = %s, want %s", tt.name, tt.total, tt.den, tt.ratios, got, tt.want)
+		}
+	}
+}
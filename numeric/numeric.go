@@ -1,11 +1,17 @@
 package numeric
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 )
 
+// MaxPrecision is the maximum number of digits after the decimal point
+// used by DecimalString.
+const MaxPrecision = 10
+
 // base type of Numeric
 type numint int64
 
@@ -30,6 +36,15 @@ func (z Numeric) String() string {
 	}
 }
 
+// FractionString returns a string representation of z in the "num/den"
+// form used in the GnuCash files.
+func (z Numeric) FractionString() string {
+	if z.den == 0 {
+		return "0/1"
+	}
+	return fmt.Sprintf("%d/%d", z.num, z.den)
+}
+
 // New creates a new numeric with numerator num and denominator den.
 func New(num, den numint) Numeric {
 	if den < 0 {
@@ -68,6 +83,48 @@ func FromString(v string) (Numeric, error) {
 	return z, nil
 }
 
+// FromDecimal creates a new Numeric from a string in decimal form
+// (e.g. "-1234.56"). The denominator is 10^(number of decimal digits).
+func FromDecimal(v string) (Numeric, error) {
+	var z Numeric
+
+	v = strings.TrimSpace(v)
+	idx := strings.IndexByte(v, '.')
+	if idx < 0 {
+		num1, err := _atoi(v)
+		if err != nil {
+			return z, err
+		}
+		return Numeric{num: num1, den: 1}, nil
+	}
+	decimals := v[idx+1:]
+	if len(decimals) == 0 || strings.IndexAny(decimals, "+-") >= 0 {
+		return z, fmt.Errorf("Invalid decimal number: %q", v)
+	}
+	num1, err := _atoi(v[:idx] + decimals)
+	if err != nil {
+		return z, err
+	}
+	den1 := numint(1)
+	for range decimals {
+		den1 *= 10
+	}
+	return Numeric{num: num1, den: den1}, nil
+}
+
+// ConvertDen returns z expressed with the denominator den.
+// It returns an error if z can't be exactly represented with den.
+func (z *Numeric) ConvertDen(den int64) (Numeric, error) {
+	if den <= 0 {
+		return Numeric{}, errors.New("Denominator must be positive")
+	}
+	units, ok := z.units(den)
+	if !ok || !units.IsInt64() {
+		return Numeric{}, fmt.Errorf("%s is not representable with denominator %d", z, den)
+	}
+	return Numeric{num: numint(units.Int64()), den: numint(den)}, nil
+}
+
 // Set sets z to the value of x.
 func (z *Numeric) Set(x *Numeric) {
 	z.num, z.den = x.num, x.den
@@ -143,11 +200,44 @@ func Sub(x *Numeric, y *Numeric) Numeric {
 	return z
 }
 
+// Cmp compares x and y and returns:
+//
+//	-1 if x <  y
+//	 0 if x == y
+//	+1 if x >  y
+func Cmp(x *Numeric, y *Numeric) int {
+	return x.Rat().Cmp(y.Rat())
+}
+
 // Neg function
 func Neg(x *Numeric) Numeric {
 	return Numeric{num: -x.num, den: x.den}
 }
 
+// FromRat returns r as a Numeric.
+// An error is returned if numerator or denominator overflow.
+func FromRat(r *big.Rat) (Numeric, error) {
+	if !r.Num().IsInt64() || !r.Denom().IsInt64() {
+		return Numeric{}, errors.New("Numeric overflow")
+	}
+	return New(numint(r.Num().Int64()), numint(r.Denom().Int64())), nil
+}
+
+// Mul returns x*y.
+// An error is returned if the result overflows.
+func Mul(x *Numeric, y *Numeric) (Numeric, error) {
+	return FromRat(new(big.Rat).Mul(x.Rat(), y.Rat()))
+}
+
+// Quo returns x/y.
+// An error is returned if y is zero or the result overflows.
+func Quo(x *Numeric, y *Numeric) (Numeric, error) {
+	if y.Sign() == 0 {
+		return Numeric{}, errors.New("Division by zero")
+	}
+	return FromRat(new(big.Rat).Quo(x.Rat(), y.Rat()))
+}
+
 // Float64 function
 func (z *Numeric) Float64() float64 {
 	if z.num == 0 || z.den == 0 {
@@ -156,6 +246,40 @@ func (z *Numeric) Float64() float64 {
 	return float64(z.num) / float64(z.den)
 }
 
+// Rat returns z as a big.Rat.
+func (z *Numeric) Rat() *big.Rat {
+	if z.den == 0 {
+		return new(big.Rat)
+	}
+	return big.NewRat(int64(z.num), int64(z.den))
+}
+
+// FloatString returns a string representation of z in decimal form with prec
+// digits of precision after the decimal point. The last digit is rounded to
+// nearest, with halves rounded away from zero.
+func (z Numeric) FloatString(prec int) string {
+	return z.Rat().FloatString(prec)
+}
+
+// DecimalString returns a string representation of z in decimal form with
+// at least prec digits after the decimal point. Further digits are added,
+// up to the greater of prec and MaxPrecision, while needed to represent z
+// exactly.
+func (z Numeric) DecimalString(prec int) string {
+	limit := MaxPrecision
+	if prec > limit {
+		limit = prec
+	}
+	r := z.Rat()
+	for p := prec; p < limit; p++ {
+		x := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p)), nil)))
+		if x.IsInt() {
+			return r.FloatString(p)
+		}
+	}
+	return r.FloatString(limit)
+}
+
 //*************************************************************
 //*************************************************************
 //*************************************************************
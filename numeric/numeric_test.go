@@ -0,0 +1,30 @@
+package numeric
+
+import "testing"
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		n    string
+		prec int
+		want string
+	}{
+		{"1234/100", 2, "12.34"},
+		{"1234/100", 0, "12.34"},
+		{"5/1", 2, "5.00"},
+		{"5/1", 0, "5"},
+		{"1/8", 2, "0.125"},
+		{"-1/8", 0, "-0.125"},
+		{"1/3", 2, "0.3333333333"},
+		{"1/3", 12, "0.333333333333"},
+		{"1/1", 12, "1.000000000000"},
+	}
+	for _, tt := range tests {
+		n, err := FromString(tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := n.DecimalString(tt.prec); got != tt.want {
+			t.Errorf("DecimalString(%s, %d) = %s, want %s", tt.n, tt.prec, got, tt.want)
+		}
+	}
+}
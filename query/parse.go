@@ -0,0 +1,203 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// token kinds
+const (
+	tokEOF = iota
+	tokWord
+	tokString
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  int
+	value string
+	pos   int
+}
+
+// lex splits the query string in tokens
+func lex(s string) ([]token, error) {
+	var tokens []token
+	r := []rune(s)
+	for j := 0; j < len(r); {
+		c := r[j]
+		switch {
+		case unicode.IsSpace(c):
+			j++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", j})
+			j++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", j})
+			j++
+		case c == '"':
+			start := j
+			var b strings.Builder
+			for j++; j < len(r) && r[j] != '"'; j++ {
+				if r[j] == '\\' && j+1 < len(r) {
+					j++
+				}
+				b.WriteRune(r[j])
+			}
+			if j >= len(r) {
+				return nil, fmt.Errorf("Unterminated string at position %d", start)
+			}
+			j++
+			tokens = append(tokens, token{tokString, b.String(), start})
+		default:
+			start := j
+			for j < len(r) && !unicode.IsSpace(r[j]) && !strings.ContainsRune(`()"`, r[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokWord, string(r[start:j]), start})
+		}
+	}
+	tokens = append(tokens, token{tokEOF, "", len(r)})
+	return tokens, nil
+}
+
+// parser type: recursive descent parser of the query language
+//
+//	expr    := and { "or" and }
+//	and     := not { ["and"] not }
+//	not     := "not" not | primary
+//	primary := "(" expr ")" | term
+//	term    := field op value | value
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword returns true if the token is the (case insensitive) keyword
+func isKeyword(t token, keyword string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.value, keyword)
+}
+
+func (p *parser) parseExpr() (Predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = or(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Predicate, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || t.kind == tokRParen || isKeyword(t, "or") {
+			return left, nil
+		}
+		if isKeyword(t, "and") {
+			p.next()
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = and(left, right)
+	}
+}
+
+func (p *parser) parseNot() (Predicate, error) {
+	if isKeyword(p.peek(), "not") {
+		p.next()
+		pred, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return not(pred), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Predicate, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		pred, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("Missing ')' for '(' at position %d", t.pos)
+		}
+		return pred, nil
+	case tokString:
+		return newTerm("", ":", t.value)
+	case tokWord:
+		field, op, value := splitTerm(t.value)
+		if op == "" {
+			// bare word: search description and memo
+			return newTerm("", ":", t.value)
+		}
+		if value == "" && p.peek().kind == tokString {
+			value = p.next().value
+		}
+		pred, err := newTerm(field, op, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s (at position %d)", err, t.pos)
+		}
+		return pred, nil
+	}
+	return nil, unexpected(t)
+}
+
+// unexpected returns the error of an unexpected token
+func unexpected(t token) error {
+	if t.kind == tokEOF {
+		return errors.New("Unexpected end of query")
+	}
+	return fmt.Errorf("Unexpected '%s' at position %d", t.value, t.pos)
+}
+
+// operators, longest first
+var operators = []string{">=", "<=", "!=", ":", "~", "=", ">", "<"}
+
+// splitTerm splits a word like "date>=2015-01-01" in field, operator and value.
+// The operator is empty if the word doesn't start with a field name.
+func splitTerm(word string) (field, op, value string) {
+	j := 0
+	for j < len(word) && (word[j] >= 'a' && word[j] <= 'z' || word[j] >= 'A' && word[j] <= 'Z' || word[j] == '_') {
+		j++
+	}
+	if j == 0 {
+		return "", "", word
+	}
+	for _, o := range operators {
+		if strings.HasPrefix(word[j:], o) {
+			return strings.ToLower(word[:j]), o, word[j+len(o):]
+		}
+	}
+	return "", "", word
+}
@@ -0,0 +1,166 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+)
+
+func TestLex(t *testing.T) {
+	tokens, err := lex(`(desc:"a \"b\"" or x)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []token{
+		{tokLParen, "(", 0},
+		{tokWord, "desc:", 1},
+		{tokString, `a "b"`, 6},
+		{tokWord, "or", 16},
+		{tokWord, "x", 19},
+		{tokRParen, ")", 20},
+		{tokEOF, "", 21},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("lex returned %d tokens, want %d: %v", len(tokens), len(want), tokens)
+	}
+	for j := range want {
+		if tokens[j] != want[j] {
+			t.Errorf("token %d = %v, want %v", j, tokens[j], want[j])
+		}
+	}
+
+	if _, err := lex(`desc:"open`); err == nil {
+		t.Error("lex of an unterminated string: want an error")
+	}
+}
+
+func TestSplitTerm(t *testing.T) {
+	tests := []struct {
+		word, field, op, value string
+	}{
+		{"date>=2015-01-01", "date", ">=", "2015-01-01"},
+		{"amount<=50", "amount", "<=", "50"},
+		{"Account:Expenses:Auto*", "account", ":", "Expenses:Auto*"},
+		{"desc~^enel", "desc", "~", "^enel"},
+		{"state!=n", "state", "!=", "n"},
+		{"memo:", "memo", ":", ""},
+		{"benzina", "", "", "benzina"},
+		{"50", "", "", "50"},
+		{":x", "", "", ":x"},
+	}
+	for _, tt := range tests {
+		field, op, value := splitTerm(tt.word)
+		if field != tt.field || op != tt.op || value != tt.value {
+			t.Errorf("splitTerm(%q) = %q, %q, %q, want %q, %q, %q", tt.word, field, op, value, tt.field, tt.op, tt.value)
+		}
+	}
+}
+
+// testSplits returns the splits the queries are matched against
+func testSplits() []*model.AccountTransaction {
+	root := &model.Account{Name: "Root Account"}
+	expenses := &model.Account{Name: "Uscite", Parent: root}
+	fuel := &model.Account{Name: "Auto benzina", Parent: expenses}
+	bank := &model.Account{Name: "Conto corrente", Parent: root}
+
+	split := func(id string, date string, desc, memo string, account *model.Account, amount string) *model.AccountTransaction {
+		d, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			panic(err)
+		}
+		v, err := numeric.FromDecimal(amount)
+		if err != nil {
+			panic(err)
+		}
+		return &model.AccountTransaction{
+			Transaction: &model.Transaction{ID: "t" + id, Currency: "EUR", DatePosted: d, Description: desc},
+			Split:       &model.Split{ID: "s" + id, ReconciledState: "n", Memo: memo, Account: account, Value: v, Quantity: v},
+		}
+	}
+	return []*model.AccountTransaction{
+		split("1", "2015-01-27", "Benzina ENI", "", fuel, "50.00"),
+		split("2", "2015-02-05", "Stipendio", "gennaio", bank, "2000.00"),
+		split("3", "2015-02-10", "Rifornimento Q8", "città", fuel, "40.50"),
+		split("4", "2016-03-01", "Affitto", "", bank, "-650.00"),
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string // IDs of the matching splits
+	}{
+		{"", "1234"},
+		{"benzina", "1"},
+		{`"citta"`, "3"},
+		{"desc:eni", "1"},
+		{"account:Uscite", "13"},
+		{"account:Usc*", "13"},
+		{"account=uscite", ""},
+		{"account!=Conto*", "1234"},
+		{"date:2015-02", "23"},
+		{"date>=2015-02-05", "234"},
+		{"date>2015", "4"},
+		{"date<2015-02-05", "1"},
+		{"date<=2015-02", "123"},
+		{"amount>50", "2"},
+		{"amount>=50", "12"},
+		{"amt<0", "4"},
+		{"amount=40.5", "3"},
+		{"account:Uscite and amount>45", "1"},
+		{"account:Uscite amount>45", "1"},
+		{"desc:eni or desc:affitto", "14"},
+		{"not account:Uscite", "24"},
+		{"account:Uscite and not (desc:eni or memo:citta)", ""},
+		{"(desc:eni or desc:stipendio) and date>=2015-02-01", "2"},
+		{"memo:", "1234"},
+		{`memo:"genn"`, "2"},
+		{"desc~^(benzina|affitto)$", ""},
+		{"desc~^benzina", "1"},
+		{"state=N", "1234"},
+		{"id=s3", "3"},
+		{"currency=usd", ""},
+	}
+	splits := testSplits()
+	for _, tt := range tests {
+		q, err := Compile(tt.query)
+		if err != nil {
+			t.Errorf("Compile(%q): unexpected error: %v", tt.query, err)
+			continue
+		}
+		got := ""
+		for _, at := range splits {
+			if q.Match(at) {
+				got += at.Split.ID[1:]
+			}
+		}
+		if got != tt.want {
+			t.Errorf("Compile(%q) matches %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		"(desc:eni",
+		"desc:eni)",
+		"not",
+		"desc:eni or",
+		"foo:bar",
+		"date:2015-13",
+		"date~2015",
+		"amount>abc",
+		"amount~1",
+		"desc<x",
+		"account>x",
+		"desc~(",
+		`"open`,
+	}
+	for _, query := range tests {
+		if _, err := Compile(query); err == nil {
+			t.Errorf("Compile(%q): want an error", query)
+		}
+	}
+}
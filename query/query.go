@@ -0,0 +1,118 @@
+// Package query implements a small language to search the splits of a book.
+//
+// A query is a list of terms, implicitly joined by "and", that can be
+// combined with the "and", "or" and "not" operators and parentheses:
+//
+//	account:Expenses:Auto* and date>=2015-01-01 and amount>50 and desc~"benzina"
+//
+// Each term has the form field op value. The fields are:
+//
+//	account     full name of the account of the split
+//	desc        description of the transaction
+//	memo        memo of the split
+//	text        description or memo
+//	date        date posted of the transaction (YYYY, YYYY-MM or YYYY-MM-DD)
+//	amount      value of the split
+//	quantity    quantity of the split
+//	reconciled  reconciled state of the split (n, c, y, f, v)
+//	currency    currency of the transaction
+//	id          ID of the transaction or of the split
+//
+// The operators are:
+//
+//	:   text fields: contains (case and accent insensitive);
+//	    account: glob pattern (* and ?) matching the account or a parent;
+//	    date: in the given day, month or year; numbers: equal
+//	=   equal (case insensitive for text fields)
+//	!=  not equal
+//	~   matches the regular expression (case insensitive)
+//	< <= > >= comparison (date and numeric fields only)
+//
+// A bare word or string searches the description and the memo.
+package query
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mmbros/gnucash-viewer/model"
+)
+
+// Predicate type: a compiled query condition
+type Predicate func(at *model.AccountTransaction) bool
+
+func and(a, b Predicate) Predicate {
+	return func(at *model.AccountTransaction) bool { return a(at) && b(at) }
+}
+
+func or(a, b Predicate) Predicate {
+	return func(at *model.AccountTransaction) bool { return a(at) || b(at) }
+}
+
+func not(a Predicate) Predicate {
+	return func(at *model.AccountTransaction) bool { return !a(at) }
+}
+
+// Query type
+type Query struct {
+	source string
+	pred   Predicate
+}
+
+// Compile parses the query string.
+// The empty query matches all the splits.
+func Compile(q string) (*Query, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	query := &Query{source: q}
+	if len(tokens) == 1 {
+		// empty query
+		query.pred = func(*model.AccountTransaction) bool { return true }
+		return query, nil
+	}
+	p := &parser{tokens: tokens}
+	if query.pred, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, unexpected(t)
+	}
+	return query, nil
+}
+
+// String returns the source of the query.
+func (q *Query) String() string {
+	return q.source
+}
+
+// Match returns true if the split (with its transaction and account
+// context) satisfies the query.
+func (q *Query) Match(at *model.AccountTransaction) bool {
+	return q.pred(at)
+}
+
+// Search returns the splits of the book matching the query, each with
+// its AccountTransaction context, sorted by date posted.
+func (q *Query) Search(book *model.Book) []*model.AccountTransaction {
+	res := []*model.AccountTransaction{}
+	for _, a := range book.Accounts.Map {
+		for _, at := range a.AccountTransactionList {
+			if q.pred(at) {
+				res = append(res, at)
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		ti, tj := res[i].Transaction, res[j].Transaction
+		if !ti.DatePosted.Equal(tj.DatePosted) {
+			return ti.DatePosted.Before(tj.DatePosted)
+		}
+		if ti.ID != tj.ID {
+			return ti.ID < tj.ID
+		}
+		return strings.Compare(res[i].Split.ID, res[j].Split.ID) < 0
+	})
+	return res
+}
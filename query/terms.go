@@ -0,0 +1,205 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mmbros/gnucash-viewer/model"
+	"github.com/mmbros/gnucash-viewer/numeric"
+	"github.com/mmbros/gnucash-viewer/text"
+)
+
+// field aliases
+var fieldAliases = map[string]string{
+	"acc":         "account",
+	"description": "desc",
+	"amt":         "amount",
+	"value":       "amount",
+	"qty":         "quantity",
+	"state":       "reconciled",
+}
+
+// textFields returns the values of the text fields
+var textFields = map[string]func(at *model.AccountTransaction) []string{
+	"desc": func(at *model.AccountTransaction) []string { return []string{at.Transaction.Description} },
+	"memo": func(at *model.AccountTransaction) []string { return []string{at.Split.Memo} },
+	"text": func(at *model.AccountTransaction) []string {
+		return []string{at.Transaction.Description, at.Split.Memo}
+	},
+	"reconciled": func(at *model.AccountTransaction) []string { return []string{at.Split.ReconciledState} },
+	"currency":   func(at *model.AccountTransaction) []string { return []string{at.Transaction.Currency} },
+	"id":         func(at *model.AccountTransaction) []string { return []string{at.Transaction.ID, at.Split.ID} },
+}
+
+// numericFields returns the values of the numeric fields
+var numericFields = map[string]func(at *model.AccountTransaction) *numeric.Numeric{
+	"amount":   func(at *model.AccountTransaction) *numeric.Numeric { return &at.Split.Value },
+	"quantity": func(at *model.AccountTransaction) *numeric.Numeric { return &at.Split.Quantity },
+}
+
+// fold returns s in the form used for case and accent insensitive comparisons
+func fold(s string) string {
+	return strings.ToLower(text.RemoveAccents(s))
+}
+
+// newTerm returns the predicate of the term "field op value".
+// The empty field searches the description and the memo.
+func newTerm(field, op, value string) (Predicate, error) {
+	if field == "" {
+		field = "text"
+	}
+	if alias, ok := fieldAliases[field]; ok {
+		field = alias
+	}
+
+	switch {
+	case field == "account":
+		return accountTerm(op, value)
+	case field == "date":
+		return dateTerm(op, value)
+	case numericFields[field] != nil:
+		return numericTerm(numericFields[field], op, value)
+	case textFields[field] != nil:
+		return textTerm(textFields[field], op, value)
+	}
+	return nil, fmt.Errorf("Unknown field: %s", field)
+}
+
+// anyOf returns the predicate true if any value of get satisfies test
+func anyOf(get func(at *model.AccountTransaction) []string, test func(s string) bool) Predicate {
+	return func(at *model.AccountTransaction) bool {
+		for _, s := range get(at) {
+			if test(s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func textTerm(get func(at *model.AccountTransaction) []string, op, value string) (Predicate, error) {
+	switch op {
+	case ":":
+		v := fold(value)
+		return anyOf(get, func(s string) bool { return strings.Contains(fold(s), v) }), nil
+	case "=":
+		return anyOf(get, func(s string) bool { return strings.EqualFold(s, value) }), nil
+	case "!=":
+		return not(anyOf(get, func(s string) bool { return strings.EqualFold(s, value) })), nil
+	case "~":
+		re, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, err
+		}
+		return anyOf(get, re.MatchString), nil
+	}
+	return nil, fmt.Errorf("Invalid operator for text field: %s", op)
+}
+
+// globToRegexp converts a glob pattern (* and ?) to a regular expression
+func globToRegexp(glob string) string {
+	s := regexp.QuoteMeta(glob)
+	s = strings.Replace(s, `\*`, `.*`, -1)
+	s = strings.Replace(s, `\?`, `.`, -1)
+	return s
+}
+
+func accountTerm(op, value string) (Predicate, error) {
+	var re *regexp.Regexp
+	var err error
+	switch op {
+	case ":":
+		// the account or one of its parents matches the pattern
+		re, err = regexp.Compile("(?i)^" + globToRegexp(value) + "(" + regexp.QuoteMeta(model.AccountSeparator) + ".*)?$")
+	case "=", "!=":
+		re, err = regexp.Compile("(?i)^" + regexp.QuoteMeta(value) + "$")
+	case "~":
+		re, err = regexp.Compile("(?i)" + value)
+	default:
+		return nil, fmt.Errorf("Invalid operator for account field: %s", op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pred := func(at *model.AccountTransaction) bool {
+		return re.MatchString(at.Split.Account.FullName())
+	}
+	if op == "!=" {
+		return not(pred), nil
+	}
+	return pred, nil
+}
+
+// parsePeriod parses a date in the YYYY, YYYY-MM or YYYY-MM-DD form
+// and returns the period [start, end) it represents.
+func parsePeriod(value string) (start, end time.Time, err error) {
+	layouts := []struct {
+		layout  string
+		y, m, d int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	}
+	for _, l := range layouts {
+		if start, err = time.ParseInLocation(l.layout, value, time.Local); err == nil {
+			return start, start.AddDate(l.y, l.m, l.d), nil
+		}
+	}
+	return start, end, fmt.Errorf("Invalid date: %s", value)
+}
+
+func dateTerm(op, value string) (Predicate, error) {
+	start, end, err := parsePeriod(value)
+	if err != nil {
+		return nil, err
+	}
+	in := func(at *model.AccountTransaction) bool {
+		d := at.Transaction.DatePosted
+		return !d.Before(start) && d.Before(end)
+	}
+	switch op {
+	case ":", "=":
+		return in, nil
+	case "!=":
+		return not(in), nil
+	case ">=":
+		return func(at *model.AccountTransaction) bool { return !at.Transaction.DatePosted.Before(start) }, nil
+	case ">":
+		return func(at *model.AccountTransaction) bool { return !at.Transaction.DatePosted.Before(end) }, nil
+	case "<":
+		return func(at *model.AccountTransaction) bool { return at.Transaction.DatePosted.Before(start) }, nil
+	case "<=":
+		return func(at *model.AccountTransaction) bool { return at.Transaction.DatePosted.Before(end) }, nil
+	}
+	return nil, fmt.Errorf("Invalid operator for date field: %s", op)
+}
+
+func numericTerm(get func(at *model.AccountTransaction) *numeric.Numeric, op, value string) (Predicate, error) {
+	v, err := numeric.FromDecimal(value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid number: %s", value)
+	}
+	var test func(cmp int) bool
+	switch op {
+	case ":", "=":
+		test = func(cmp int) bool { return cmp == 0 }
+	case "!=":
+		test = func(cmp int) bool { return cmp != 0 }
+	case ">":
+		test = func(cmp int) bool { return cmp > 0 }
+	case ">=":
+		test = func(cmp int) bool { return cmp >= 0 }
+	case "<":
+		test = func(cmp int) bool { return cmp < 0 }
+	case "<=":
+		test = func(cmp int) bool { return cmp <= 0 }
+	default:
+		return nil, fmt.Errorf("Invalid operator for numeric field: %s", op)
+	}
+	return func(at *model.AccountTransaction) bool {
+		return test(numeric.Cmp(get(at), &v))
+	}, nil
+}
@@ -0,0 +1,245 @@
+package xml
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// File is the raw content of a GnuCash file in XML format.
+// It is edited in place, so that all the elements not handled by this
+// package are preserved when the file is written back.
+type File struct {
+	data []byte
+}
+
+// ReadRawFile reads the raw content of the gnucash file in XML format
+func ReadRawFile(path string) (*File, error) {
+
+	// open gnucash file
+	gnucashFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer gnucashFile.Close()
+
+	// decompress gnucash file
+	reader, err := gzip.NewReader(gnucashFile)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &File{data: data}, nil
+}
+
+// newFileMode is the mode of the files written that didn't exist
+const newFileMode = 0644
+
+// WriteFile writes the compressed gnucash file. The file is first written
+// to a temporary file in the same directory and then renamed, keeping the
+// mode of the file it replaces. Unless force is true, the file is not
+// written if GnuCash has it open, i.e. if the lock file path.LCK exists.
+func (f *File) WriteFile(path string, force bool) error {
+	if _, err := os.Stat(path + ".LCK"); err == nil && !force {
+		return fmt.Errorf("File locked by GnuCash: %s.LCK exists", path)
+	}
+	mode := os.FileMode(newFileMode)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(mode)
+	if err == nil {
+		w := gzip.NewWriter(tmp)
+		if _, err = w.Write(f.data); err == nil {
+			err = w.Close()
+		}
+	}
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// NewGUID returns a new random GUID in the GnuCash format
+// (32 hexadecimal digits).
+func NewGUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// reCountTransaction matches the transaction count of the book
+var reCountTransaction = regexp.MustCompile(`<gnc:count-data cd:type="transaction">(\d+)</gnc:count-data>`)
+
+// bookRange returns the start and the end of the content of the book with
+// the given ID. The empty ID means the last book of the file.
+func (f *File) bookRange(bookID string) (start, end int, err error) {
+	if bookID == "" {
+		start = bytes.LastIndex(f.data, []byte("<gnc:book"))
+	} else {
+		start = bytes.Index(f.data, []byte(fmt.Sprintf("<book:id type=\"guid\">%s</book:id>", escape(bookID))))
+	}
+	if start < 0 {
+		return 0, 0, fmt.Errorf("BOOK not found: %s", bookID)
+	}
+	end = bytes.Index(f.data[start:], []byte("</gnc:book>"))
+	if end < 0 {
+		return 0, 0, errors.New("Invalid BOOK: missing end tag")
+	}
+	return start, start + end, nil
+}
+
+// AddTransactions appends the transactions to the book with the given ID
+// (the last book of the file if empty).
+func (f *File) AddTransactions(bookID string, list []Transaction) error {
+	if len(list) == 0 {
+		return nil
+	}
+	start, end, err := f.bookRange(bookID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for j := range list {
+		writeTransaction(&buf, &list[j])
+	}
+
+	data := splice(f.data, end, end, buf.Bytes())
+
+	// update the transaction count of the book
+	if loc := reCountTransaction.FindSubmatchIndex(data[start:end]); loc != nil {
+		count, err := strconv.Atoi(string(data[start+loc[2] : start+loc[3]]))
+		if err != nil {
+			return err
+		}
+		count += len(list)
+		data = splice(data, start+loc[2], start+loc[3], []byte(strconv.Itoa(count)))
+	}
+
+	f.data = data
+	return nil
+}
+
+// splice returns a new slice with data[start:end] replaced by repl.
+func splice(data []byte, start, end int, repl []byte) []byte {
+	res := make([]byte, 0, len(data)-(end-start)+len(repl))
+	res = append(res, data[:start]...)
+	res = append(res, repl...)
+	return append(res, data[end:]...)
+}
+
+// escape returns s with the XML special characters escaped
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func writeTransaction(buf *bytes.Buffer, t *Transaction) {
+	space := t.CurrencySpace
+	if space == "" {
+		space = "ISO4217"
+	}
+	fmt.Fprintf(buf, "<gnc:transaction version=\"2.0.0\">\n")
+	fmt.Fprintf(buf, "  <trn:id type=\"guid\">%s</trn:id>\n", escape(t.ID))
+	fmt.Fprintf(buf, "  <trn:currency>\n")
+	fmt.Fprintf(buf, "    <cmdty:space>%s</cmdty:space>\n", escape(space))
+	fmt.Fprintf(buf, "    <cmdty:id>%s</cmdty:id>\n", escape(t.Currency))
+	fmt.Fprintf(buf, "  </trn:currency>\n")
+	fmt.Fprintf(buf, "  <trn:date-posted>\n    <ts:date>%s</ts:date>\n  </trn:date-posted>\n", escape(t.DatePosted))
+	fmt.Fprintf(buf, "  <trn:date-entered>\n    <ts:date>%s</ts:date>\n  </trn:date-entered>\n", escape(t.DateEntered))
+	fmt.Fprintf(buf, "  <trn:description>%s</trn:description>\n", escape(t.Description))
+	fmt.Fprintf(buf, "  <trn:splits>\n")
+	for j := range t.SplitList {
+		writeSplit(buf, &t.SplitList[j])
+	}
+	fmt.Fprintf(buf, "  </trn:splits>\n")
+	fmt.Fprintf(buf, "</gnc:transaction>\n")
+}
+
+func writeSplit(buf *bytes.Buffer, s *Split) {
+	fmt.Fprintf(buf, "    <trn:split>\n")
+	fmt.Fprintf(buf, "      <split:id type=\"guid\">%s</split:id>\n", escape(s.ID))
+	if s.Memo != "" {
+		fmt.Fprintf(buf, "      <split:memo>%s</split:memo>\n", escape(s.Memo))
+	}
+	fmt.Fprintf(buf, "      <split:reconciled-state>%s</split:reconciled-state>\n", escape(s.ReconciledState))
+	if s.ReconcileDate != "" {
+		fmt.Fprintf(buf, "      <split:reconcile-date>\n        <ts:date>%s</ts:date>\n      </split:reconcile-date>\n", escape(s.ReconcileDate))
+	}
+	fmt.Fprintf(buf, "      <split:value>%s</split:value>\n", escape(s.Value))
+	fmt.Fprintf(buf, "      <split:quantity>%s</split:quantity>\n", escape(s.Quantity))
+	fmt.Fprintf(buf, "      <split:account type=\"guid\">%s</split:account>\n", escape(s.AccountID))
+	if len(s.Slots) > 0 {
+		fmt.Fprintf(buf, "      <split:slots>\n")
+		for _, slot := range s.Slots {
+			fmt.Fprintf(buf, "        <slot>\n")
+			fmt.Fprintf(buf, "          <slot:key>%s</slot:key>\n", escape(slot.Key))
+			fmt.Fprintf(buf, "          <slot:value type=\"%s\">%s</slot:value>\n", escape(slot.Value.Type), escape(slot.Value.Text))
+			fmt.Fprintf(buf, "        </slot>\n")
+		}
+		fmt.Fprintf(buf, "      </split:slots>\n")
+	}
+	fmt.Fprintf(buf, "    </trn:split>\n")
+}
+
+// reSplitReconcile matches the reconciled state and the optional reconcile
+// date of a split
+var reSplitReconcile = regexp.MustCompile(`<split:reconciled-state>[^<]*</split:reconciled-state>(\s*<split:reconcile-date>\s*<ts:date>[^<]*</ts:date>\s*</split:reconcile-date>)?`)
+
+// SetReconciled sets the reconciled state and the reconcile date
+// (GnuCash timestamp, omitted if empty) of the split with the given ID.
+func (f *File) SetReconciled(splitID, state, date string) error {
+	id := []byte(fmt.Sprintf("<split:id type=\"guid\">%s</split:id>", escape(splitID)))
+	start := bytes.Index(f.data, id)
+	if start < 0 {
+		return fmt.Errorf("Split not found: ID = %s", splitID)
+	}
+	end := bytes.Index(f.data[start:], []byte("</trn:split>"))
+	if end < 0 {
+		return fmt.Errorf("Invalid split: ID = %s", splitID)
+	}
+	end += start
+
+	loc := reSplitReconcile.FindIndex(f.data[start:end])
+	if loc == nil {
+		return fmt.Errorf("Reconciled state not found in split: ID = %s", splitID)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<split:reconciled-state>%s</split:reconciled-state>", escape(state))
+	if date != "" {
+		fmt.Fprintf(&buf, "\n      <split:reconcile-date>\n        <ts:date>%s</ts:date>\n      </split:reconcile-date>", escape(date))
+	}
+	f.data = splice(f.data, start+loc[0], start+loc[1], buf.Bytes())
+	return nil
+}
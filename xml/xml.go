@@ -27,8 +27,252 @@ type Gnc struct {
 type Book struct {
 	XMLName         xml.Name      `xml:"book"`
 	ID              string        `xml:"id"`
+	Slots           []Slot        `xml:"slots>slot"`
+	CommodityList   []Commodity   `xml:"commodity"`
+	PriceList       []Price       `xml:"pricedb>price"`
 	AccountList     []Account     `xml:"account"`
 	TransactionList []Transaction `xml:"transaction"`
+	TemplateList    []Template    `xml:"template-transactions"`
+
+	ScheduledTransactionList []ScheduledTransaction `xml:"schedxaction"`
+	BudgetList               []Budget               `xml:"budget"`
+
+	// business data
+	CustomerList []Customer `xml:"GncCustomer"`
+	VendorList   []Vendor   `xml:"GncVendor"`
+	EmployeeList []Employee `xml:"GncEmployee"`
+	JobList      []Job      `xml:"GncJob"`
+	InvoiceList  []Invoice  `xml:"GncInvoice"`
+	EntryList    []Entry    `xml:"GncEntry"`
+	TaxTableList []TaxTable `xml:"GncTaxTable"`
+	BillTermList []BillTerm `xml:"GncBillTerm"`
+}
+
+// Template type: the template accounts and transactions of the
+// scheduled transactions
+type Template struct {
+	AccountList     []Account     `xml:"account"`
+	TransactionList []Transaction `xml:"transaction"`
+}
+
+// ScheduledTransaction type
+type ScheduledTransaction struct {
+	ID                string       `xml:"id"`
+	Name              string       `xml:"name"`
+	Enabled           string       `xml:"enabled"`
+	AutoCreate        string       `xml:"autoCreate"`
+	AdvanceCreateDays string       `xml:"advanceCreateDays"`
+	AdvanceRemindDays string       `xml:"advanceRemindDays"`
+	InstanceCount     string       `xml:"instanceCount"`
+	Start             string       `xml:"start>gdate"`
+	Last              string       `xml:"last>gdate"`
+	End               string       `xml:"end>gdate"`
+	NumOccur          string       `xml:"num-occur"`
+	RemOccur          string       `xml:"rem-occur"`
+	TemplateAccountID string       `xml:"templ-acct"`
+	Schedule          []Recurrence `xml:"schedule>recurrence"`
+}
+
+// Budget type: the amounts of the budget are in slots, with the account
+// ID as key of a frame and the period number as key of each amount
+type Budget struct {
+	ID          string     `xml:"id"`
+	Name        string     `xml:"name"`
+	Description string     `xml:"description"`
+	NumPeriods  string     `xml:"num-periods"`
+	Recurrence  Recurrence `xml:"recurrence"`
+	Slots       []Slot     `xml:"slots>slot"`
+}
+
+// Recurrence type
+type Recurrence struct {
+	Mult       string `xml:"mult"`
+	PeriodType string `xml:"period_type"`
+	Start      string `xml:"start>gdate"`
+	WeekendAdj string `xml:"weekend_adj"`
+}
+
+// Address type: the address of a business owner
+type Address struct {
+	Name  string `xml:"name"`
+	Addr1 string `xml:"addr1"`
+	Addr2 string `xml:"addr2"`
+	Addr3 string `xml:"addr3"`
+	Addr4 string `xml:"addr4"`
+	Phone string `xml:"phone"`
+	Fax   string `xml:"fax"`
+	Email string `xml:"email"`
+}
+
+// Owner type: the reference to the owner of a job or invoice.
+// Type is gncCustomer, gncVendor, gncEmployee or gncJob.
+type Owner struct {
+	Type string `xml:"type"`
+	ID   string `xml:"id"`
+}
+
+// Customer type
+type Customer struct {
+	GUID        string  `xml:"guid"`
+	Name        string  `xml:"name"`
+	ID          string  `xml:"id"`
+	Address     Address `xml:"addr"`
+	ShipAddress Address `xml:"shipaddr"`
+	Notes       string  `xml:"notes"`
+	TermsID     string  `xml:"terms"`
+	TaxIncluded string  `xml:"taxincluded"`
+	Active      string  `xml:"active"`
+	Discount    string  `xml:"discount"`
+	Credit      string  `xml:"credit"`
+	Currency    string  `xml:"currency>id"`
+	TaxTableID  string  `xml:"taxtable"`
+	Slots       []Slot  `xml:"slots>slot"`
+}
+
+// Vendor type
+type Vendor struct {
+	GUID        string  `xml:"guid"`
+	Name        string  `xml:"name"`
+	ID          string  `xml:"id"`
+	Address     Address `xml:"addr"`
+	Notes       string  `xml:"notes"`
+	TermsID     string  `xml:"terms"`
+	TaxIncluded string  `xml:"taxincluded"`
+	Active      string  `xml:"active"`
+	Currency    string  `xml:"currency>id"`
+	TaxTableID  string  `xml:"taxtable"`
+	Slots       []Slot  `xml:"slots>slot"`
+}
+
+// Employee type
+type Employee struct {
+	GUID         string  `xml:"guid"`
+	Username     string  `xml:"username"`
+	ID           string  `xml:"id"`
+	Address      Address `xml:"addr"`
+	Language     string  `xml:"language"`
+	Active       string  `xml:"active"`
+	Workday      string  `xml:"workday"`
+	Rate         string  `xml:"rate"`
+	Currency     string  `xml:"currency>id"`
+	CreditCardID string  `xml:"ccard"`
+	Slots        []Slot  `xml:"slots>slot"`
+}
+
+// Job type
+type Job struct {
+	GUID      string `xml:"guid"`
+	ID        string `xml:"id"`
+	Name      string `xml:"name"`
+	Reference string `xml:"reference"`
+	Owner     Owner  `xml:"owner"`
+	Active    string `xml:"active"`
+}
+
+// Invoice type: a customer invoice, a vendor bill or an employee voucher
+type Invoice struct {
+	GUID          string `xml:"guid"`
+	ID            string `xml:"id"`
+	Owner         Owner  `xml:"owner"`
+	Opened        string `xml:"opened>date"`
+	Posted        string `xml:"posted>date"`
+	TermsID       string `xml:"terms"`
+	BillingID     string `xml:"billing_id"`
+	Notes         string `xml:"notes"`
+	Active        string `xml:"active"`
+	PostTxnID     string `xml:"posttxn"`
+	PostLotID     string `xml:"postlot"`
+	PostAccountID string `xml:"postacc"`
+	Currency      string `xml:"currency>id"`
+	BillTo        Owner  `xml:"billto"`
+	Slots         []Slot `xml:"slots>slot"`
+}
+
+// Entry type: a line of an invoice (i- fields) or of a bill (b- fields)
+type Entry struct {
+	GUID        string `xml:"guid"`
+	Date        string `xml:"date>date"`
+	Entered     string `xml:"entered>date"`
+	Description string `xml:"description"`
+	Action      string `xml:"action"`
+	Notes       string `xml:"notes"`
+	Quantity    string `xml:"qty"`
+
+	InvoiceAccountID   string `xml:"i-acct"`
+	InvoicePrice       string `xml:"i-price"`
+	InvoiceDiscount    string `xml:"i-discount"`
+	InvoiceID          string `xml:"invoice"`
+	InvoiceDiscType    string `xml:"i-disc-type"`
+	InvoiceDiscHow     string `xml:"i-disc-how"`
+	InvoiceTaxable     string `xml:"i-taxable"`
+	InvoiceTaxIncluded string `xml:"i-taxincluded"`
+	InvoiceTaxTableID  string `xml:"i-taxtable"`
+
+	BillAccountID   string `xml:"b-acct"`
+	BillPrice       string `xml:"b-price"`
+	BillID          string `xml:"bill"`
+	BillTaxable     string `xml:"b-taxable"`
+	BillTaxIncluded string `xml:"b-taxincluded"`
+	BillTaxTableID  string `xml:"b-taxtable"`
+	BillPayment     string `xml:"b-pay"`
+}
+
+// TaxTable type
+type TaxTable struct {
+	GUID      string          `xml:"guid"`
+	Name      string          `xml:"name"`
+	Invisible string          `xml:"invisible"`
+	ParentID  string          `xml:"parent"`
+	Entries   []TaxTableEntry `xml:"entries>GncTaxTableEntry"`
+}
+
+// TaxTableEntry type: Type is PERCENT or VALUE
+type TaxTableEntry struct {
+	AccountID string `xml:"acct"`
+	Amount    string `xml:"amount"`
+	Type      string `xml:"type"`
+}
+
+// BillTerm type: the due date is given either in days or as proximo
+// (a day of a following month)
+type BillTerm struct {
+	GUID        string        `xml:"guid"`
+	Name        string        `xml:"name"`
+	Description string        `xml:"desc"`
+	Invisible   string        `xml:"invisible"`
+	Days        *BillTermDays `xml:"days"`
+	Proximo     *BillTermDays `xml:"proximo"`
+}
+
+// BillTermDays type
+type BillTermDays struct {
+	DueDays      string `xml:"due-days"`
+	DiscountDays string `xml:"disc-days"`
+	Discount     string `xml:"discount"`
+	DueDay       string `xml:"due-day"`
+	DiscountDay  string `xml:"disc-day"`
+	CutoffDay    string `xml:"cutoff-day"`
+}
+
+// Commodity type
+type Commodity struct {
+	Space    string `xml:"space"`
+	ID       string `xml:"id"`
+	Name     string `xml:"name"`
+	Fraction string `xml:"fraction"`
+}
+
+// Price type
+type Price struct {
+	ID             string `xml:"id"`
+	CommoditySpace string `xml:"commodity>space"`
+	Commodity      string `xml:"commodity>id"`
+	CurrencySpace  string `xml:"currency>space"`
+	Currency       string `xml:"currency>id"`
+	Time           string `xml:"time>date"`
+	Source         string `xml:"source"`
+	Type           string `xml:"type"`
+	Value          string `xml:"value"`
 }
 
 // Account type
@@ -39,6 +283,14 @@ type Account struct {
 	Description string `xml:"description"`
 	ParentID    string `xml:"parent"`
 	Currency    string `xml:"commodity>id"`
+	Slots       []Slot `xml:"slots>slot"`
+	Lots        []Lot  `xml:"lots>lot"`
+}
+
+// Lot type
+type Lot struct {
+	ID    string `xml:"id"`
+	Slots []Slot `xml:"slots>slot"`
 }
 
 // Split type
@@ -50,16 +302,20 @@ type Split struct {
 	Memo            string `xml:"memo"`
 	Quantity        string `xml:"quantity"`
 	AccountID       string `xml:"account"`
+	LotID           string `xml:"lot"`
+	Slots           []Slot `xml:"slots>slot"`
 }
 
 // Transaction type
 type Transaction struct {
-	ID          string  `xml:"id"`
-	Currency    string  `xml:"currency>id"`
-	DatePosted  string  `xml:"date-posted>date"`
-	DateEntered string  `xml:"date-entered>date"`
-	Description string  `xml:"description"`
-	SplitList   []Split `xml:"splits>split"`
+	ID            string  `xml:"id"`
+	CurrencySpace string  `xml:"currency>space"`
+	Currency      string  `xml:"currency>id"`
+	DatePosted    string  `xml:"date-posted>date"`
+	DateEntered   string  `xml:"date-entered>date"`
+	Description   string  `xml:"description"`
+	Slots         []Slot  `xml:"slots>slot"`
+	SplitList     []Split `xml:"splits>split"`
 }
 
 /*
@@ -107,12 +363,19 @@ type Transaction struct {
 
 */
 
-// Slot type : integer | string | frame | gdate | numeric
+// Slot type
 type Slot struct {
-	XMLName  xml.Name `xml:"slot"`
-	Key      string   `xml:"key"`
-	Value    string   `xml:"value"`
-	Children []*Slot
+	Key   string    `xml:"key"`
+	Value SlotValue `xml:"value"`
+}
+
+// SlotValue type : integer | string | frame | gdate | numeric | guid | timespec
+type SlotValue struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	GDate    string `xml:"gdate"`
+	TimeSpec string `xml:"date"`
+	Slots    []Slot `xml:"slot"`
 }
 
 // ReadFile read the gnucash file in XML format